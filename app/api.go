@@ -1,14 +1,19 @@
 package app
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/grpc"
 )
 
 type APITest struct {
@@ -19,7 +24,49 @@ type APITest struct {
 	responseBody  string
 	commandOutput string
 	store         map[string]any
+	lastRequest   *lastRequest
+	timing        *RequestTiming
+	timings       *Timings
+	scenario      string
 	debug         bool
+	maskSecrets   bool
+	strict        bool
+	seed          uint64
+	faker         *gofakeit.Faker
+	locale        string
+	unique        map[string]map[string]struct{}
+	generators    map[string]Generator
+	mocks         map[string]*mockServer
+	webhooks      *webhookListener
+	webhook       string
+
+	// xmlNamespaces come from the config; scenarioXMLNamespaces are
+	// registered by steps and cleared before each scenario.
+	xmlNamespaces         map[string]string
+	scenarioXMLNamespaces map[string]string
+
+	graphQLVariables map[string]any
+	graphQLErrors    string
+
+	webSocket        *webSocketConn
+	webSocketMessage string
+
+	events *eventStream
+	event  *serverEvent
+
+	grpc             *grpcResult
+	grpcAddress      string
+	protoFiles       []string
+	protoImportPaths []string
+	// scenarioGRPCAddress and scenarioProtoFiles are set by steps and
+	// cleared before each scenario; they apply over the config.
+	scenarioGRPCAddress string
+	scenarioProtoFiles  []string
+	grpcConns           map[string]*grpc.ClientConn
+	protoDescriptors    map[string]linker.Resolver
+
+	webhookSecret          string
+	webhookSignatureHeader string
 }
 
 // Global store for variables that can be accessed from other tests
@@ -31,23 +78,107 @@ func NewAPITest(baseURL string) *APITest {
 		client:  &http.Client{},
 		headers: map[string]string{"Content-Type": "application/json"},
 		store:   globalStore,
+		faker:   gofakeit.New(0),
 	}
 }
 
-func (a *APITest) replaceVars(text string) string {
+func (a *APITest) replaceVars(text string) (string, error) {
 	r := regexp.MustCompile(`\${([^}]+)}`)
-	return r.ReplaceAllStringFunc(text, func(match string) string {
-		// Extract key name without ${ and }
-		key := match[2 : len(match)-1]
-		if val, ok := a.store[key]; ok {
-			return fmt.Sprintf("%v", val)
+	var errs []string
+	result := r.ReplaceAllStringFunc(text, func(match string) string {
+		// Extract expression without ${ and }
+		val, err := a.evalExpr(match[2 : len(match)-1])
+		if err != nil {
+			errs = append(errs, a.describeExprError(err))
+			return match
+		}
+		return formatValue(val)
+	})
+
+	if a.strict && len(errs) > 0 {
+		return "", fmt.Errorf("unresolved placeholders in %q: %s", text, strings.Join(errs, "; "))
+	}
+
+	return result, nil
+}
+
+// describeExprError explains why a placeholder could not be resolved,
+// suggesting the closest known variable names for undefined ones.
+func (a *APITest) describeExprError(err error) string {
+	undefined, ok := err.(*undefinedVarError)
+	if !ok {
+		return err.Error()
+	}
+
+	known := make([]string, 0, len(a.store))
+	for k := range a.store {
+		known = append(known, k)
+	}
+
+	if suggestions := closestMatches(undefined.name, known, 3); len(suggestions) > 0 {
+		quoted := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			quoted[i] = fmt.Sprintf("%q", s)
 		}
-		return match
+		return fmt.Sprintf("%s (did you mean %s?)", err, strings.Join(quoted, ", "))
+	}
+	return err.Error()
+}
+
+// closestMatches returns up to n candidates ordered by edit distance to
+// target, ignoring candidates that are too different to be a likely typo.
+func closestMatches(target string, candidates []string, n int) []string {
+	type match struct {
+		value    string
+		distance int
+	}
+
+	maxDistance := max(2, len(target)/3)
+	var matches []match
+	for _, c := range candidates {
+		if d := levenshtein(strings.ToLower(target), strings.ToLower(c)); d <= maxDistance {
+			matches = append(matches, match{c, d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].value < matches[j].value
 	})
+
+	var result []string
+	for i := 0; i < len(matches) && i < n; i++ {
+		result = append(result, matches[i].value)
+	}
+	return result
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
 }
 
-func generateFromTag(tag string) (string, error) {
-	faker := gofakeit.New(0)
+func generateFromTag(faker *gofakeit.Faker, tag string) (string, error) {
 	result, err := faker.Generate(tag)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate data from tag: %w", err)
@@ -129,15 +260,20 @@ func (a *APITest) makeValidJSON(template string) (string, error) {
 		return "", fmt.Errorf("malformed JSON template: %w", err)
 	}
 
+	var unresolved []string
 	var replaceInValue func(any) any
 	replaceInValue = func(val any) any {
 		switch v := val.(type) {
 		case string:
 			for placeholder, varName := range placeholderMap {
 				if strings.Contains(v, placeholder) {
-					if storeVal, ok := a.store[varName]; ok {
+					storeVal, err := a.evalExpr(varName)
+					if err == nil {
 						return storeVal
 					}
+					if a.strict {
+						unresolved = append(unresolved, a.describeExprError(err))
+					}
 				}
 			}
 			return v
@@ -159,6 +295,9 @@ func (a *APITest) makeValidJSON(template string) (string, error) {
 	}
 
 	processedObj := replaceInValue(jsonObj)
+	if len(unresolved) > 0 {
+		return "", fmt.Errorf("unresolved placeholders: %s", strings.Join(unresolved, "; "))
+	}
 
 	result, err := json.Marshal(processedObj)
 	if err != nil {
@@ -167,3 +306,95 @@ func (a *APITest) makeValidJSON(template string) (string, error) {
 
 	return string(result), nil
 }
+
+func isJSONPayload(payload string) bool {
+	trimmed := strings.TrimSpace(payload)
+	return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+}
+
+// renderJSON substitutes placeholders in a JSON template while keeping the
+// result valid JSON: placeholders inside strings are escaped as string
+// content, and bare placeholders are rendered as typed JSON values.
+func (a *APITest) renderJSON(template string) (string, error) {
+	var errs []string
+	result := substituteJSON(template, func(match string, inString bool) string {
+		val, err := a.evalExpr(match[2 : len(match)-1])
+		switch {
+		case err != nil:
+			errs = append(errs, a.describeExprError(err))
+			return match
+		case inString:
+			encoded := marshalJSON(formatValue(val))
+			return encoded[1 : len(encoded)-1]
+		default:
+			return jsonValue(val)
+		}
+	})
+
+	if a.strict && len(errs) > 0 {
+		return "", fmt.Errorf("unresolved placeholders in payload: %s", strings.Join(errs, "; "))
+	}
+
+	return result, nil
+}
+
+// substituteJSON replaces every ${...} placeholder in a JSON template with
+// the result of replace, which is told whether the placeholder sits inside a
+// JSON string.
+func substituteJSON(template string, replace func(match string, inString bool) string) string {
+	var sb strings.Builder
+	inString, escaped := false, false
+
+	for i := 0; i < len(template); {
+		c := template[i]
+
+		if c == '$' && !escaped && strings.HasPrefix(template[i:], "${") {
+			if end := strings.IndexByte(template[i:], '}'); end > 0 {
+				sb.WriteString(replace(template[i:i+end+1], inString))
+				i += end + 1
+				continue
+			}
+		}
+
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		}
+
+		sb.WriteByte(c)
+		i++
+	}
+
+	return sb.String()
+}
+
+// jsonValue renders a stored value as a JSON literal. Strings that hold a
+// JSON object or array, such as command output, are embedded as-is; other
+// strings stay strings, so an id of "42" is not sent as a number.
+func jsonValue(val any) string {
+	switch v := val.(type) {
+	case string:
+		if trimmed := strings.TrimSpace(v); (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+			return trimmed
+		}
+		return marshalJSON(v)
+	case time.Time, time.Duration:
+		return marshalJSON(formatValue(v))
+	default:
+		return marshalJSON(v)
+	}
+}
+
+func marshalJSON(val any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(val); err != nil {
+		return marshalJSON(fmt.Sprintf("%v", val))
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
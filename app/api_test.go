@@ -47,13 +47,57 @@ func TestReplaceVars(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		result := apiTest.replaceVars(test.input)
+		result, err := apiTest.replaceVars(test.input)
+		if err != nil {
+			t.Errorf("For %q, expected no error, got %v", test.input, err)
+		}
 		if result != test.expected {
 			t.Errorf("For %q, expected %q, got %q", test.input, test.expected, result)
 		}
 	}
 }
 
+func TestReplaceVarsStrict(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	apiTest.strict = true
+	apiTest.store["user_id"] = "42"
+
+	result, err := apiTest.replaceVars("/users/${user_id}")
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != "/users/42" {
+		t.Errorf("Expected %q, got %q", "/users/42", result)
+	}
+
+	_, err = apiTest.replaceVars("/users/${user_di}")
+	if err == nil {
+		t.Fatal("Expected error for undefined variable, got nil")
+	}
+	if !strings.Contains(err.Error(), `"user_di"`) || !strings.Contains(err.Error(), `did you mean "user_id"`) {
+		t.Errorf("Expected error to name the variable and suggest user_id, got %v", err)
+	}
+
+	_, err = apiTest.makeValidJSON(`{"id": ${user_di}}`)
+	if err == nil {
+		t.Error("Expected error for undefined variable in JSON template, got nil")
+	}
+}
+
+func TestClosestMatches(t *testing.T) {
+	candidates := []string{"user_id", "user_name", "token", "order_id"}
+
+	result := closestMatches("usr_id", candidates, 3)
+	if len(result) == 0 || result[0] != "user_id" {
+		t.Errorf("Expected user_id as closest match, got %v", result)
+	}
+
+	result = closestMatches("completely_different", candidates, 3)
+	if len(result) != 0 {
+		t.Errorf("Expected no matches, got %v", result)
+	}
+}
+
 func createTestServer(_ *testing.T, status int, response string) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -63,6 +107,7 @@ func createTestServer(_ *testing.T, status int, response string) *httptest.Serve
 }
 
 func TestGenerateFromTag(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
 	tests := []string{
 		"{name}",
 		"{email}",
@@ -71,7 +116,7 @@ func TestGenerateFromTag(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		result, err := generateFromTag(test)
+		result, err := generateFromTag(apiTest.faker, test)
 		if err != nil {
 			t.Errorf("For %q, expected no error, got %v", test, err)
 		}
@@ -85,7 +130,7 @@ func TestGenerateFromTag(t *testing.T) {
 
 	// Should return same string, not error
 	invalidTag := "{invalidtag}"
-	result, err := generateFromTag(invalidTag)
+	result, err := generateFromTag(apiTest.faker, invalidTag)
 	if err != nil {
 		t.Errorf("For invalid tag, expected no error, got %v", err)
 	}
@@ -169,6 +214,41 @@ func TestContainsSubset(t *testing.T) {
 	}
 }
 
+func TestRenderJSON(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	apiTest.store["count"] = float64(1000000)
+	apiTest.store["meta"] = map[string]any{"a": float64(1)}
+	apiTest.store["tags"] = `["x", "y"]`
+	apiTest.store["quote"] = "say \"hi\"\nbye"
+	apiTest.store["label"] = "plain"
+	apiTest.store["user_id"] = "42"
+	apiTest.store["flag"] = "true"
+
+	result, err := apiTest.renderJSON(`{"count": ${count}, "meta": ${meta}, "tags": ${tags}, "text": "${quote}", "label": ${label}, "id": "n-${count}", "user_id": ${user_id}, "flag": ${flag}}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(result), &data); err != nil {
+		t.Fatalf("Result is not valid JSON: %v\n%s", err, result)
+	}
+
+	expected := map[string]any{
+		"count":   float64(1000000),
+		"meta":    map[string]any{"a": float64(1)},
+		"tags":    []any{"x", "y"},
+		"text":    "say \"hi\"\nbye",
+		"label":   "plain",
+		"id":      "n-1000000",
+		"user_id": "42",
+		"flag":    "true",
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected %v, got %v", expected, data)
+	}
+}
+
 func TestMakeValidJSON(t *testing.T) {
 	apiTest := NewAPITest("https://example.com")
 	apiTest.store["name"] = "John"
@@ -176,10 +256,13 @@ func TestMakeValidJSON(t *testing.T) {
 	apiTest.store["active"] = true
 
 	template := `{"name": "${name}"}`
-	jsonString := apiTest.replaceVars(template)
+	jsonString, err := apiTest.replaceVars(template)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
 
 	var data map[string]any
-	err := json.Unmarshal([]byte(jsonString), &data)
+	err = json.Unmarshal([]byte(jsonString), &data)
 	if err != nil {
 		t.Fatalf("Result is not valid JSON: %v", err)
 	}
@@ -188,7 +271,10 @@ func TestMakeValidJSON(t *testing.T) {
 	}
 
 	template = `{"name": "${name}", "age": ${age}, "active": ${active}}`
-	jsonString = apiTest.replaceVars(template)
+	jsonString, err = apiTest.replaceVars(template)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
 
 	// For non-string values, we need to handle JSON parsing separately
 	// This simulates what makeValidJSON is trying to do
@@ -14,8 +14,14 @@ func (a *APITest) iExecuteCommand(command string) error {
 }
 
 func (a *APITest) iExecuteCommandInDirectory(command string, dir string) error {
-	command = a.replaceVars(command)
-	dir = a.replaceVars(dir)
+	command, err := a.replaceVars(command)
+	if err != nil {
+		return err
+	}
+	dir, err = a.replaceVars(dir)
+	if err != nil {
+		return err
+	}
 
 	if strings.TrimSpace(command) == "" {
 		return fmt.Errorf("command is empty")
@@ -43,7 +49,7 @@ func (a *APITest) iExecuteCommandInDirectory(command string, dir string) error {
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	a.commandOutput = strings.Trim(stdout.String(), "\n")
 	if err != nil {
 		return fmt.Errorf("command failed: %v\nStdout: %s\nStderr: %s",
@@ -73,7 +79,10 @@ func (a *APITest) iExecuteCommandWithTimeout(command string, timeoutSec int) err
 }
 
 func (a *APITest) theCommandOutputShouldMatch(expected string) error {
-	expected = a.replaceVars(expected)
+	expected, err := a.replaceVars(expected)
+	if err != nil {
+		return err
+	}
 	if a.commandOutput != expected {
 		return fmt.Errorf("expected command output to be '%s', but got '%s'", expected, a.commandOutput)
 	}
@@ -81,7 +90,10 @@ func (a *APITest) theCommandOutputShouldMatch(expected string) error {
 }
 
 func (a *APITest) theCommandOutputShouldContain(expected string) error {
-	expected = a.replaceVars(expected)
+	expected, err := a.replaceVars(expected)
+	if err != nil {
+		return err
+	}
 	if !strings.Contains(a.commandOutput, expected) {
 		return fmt.Errorf("expected command output to contain '%s', but got '%s'", expected, a.commandOutput)
 	}
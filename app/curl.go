@@ -0,0 +1,139 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// maskedValue replaces secrets in curl commands when masking is enabled.
+const maskedValue = "***"
+
+// secretNames are the header and JSON property name fragments treated as
+// secrets, compared case-insensitively.
+var secretNames = []string{"authorization", "cookie", "token", "secret", "password", "api-key", "apikey", "api_key"}
+
+// lastRequest is the request most recently sent in the current scenario,
+// kept so it can be reproduced with curl.
+type lastRequest struct {
+	method  string
+	url     string
+	headers http.Header
+	body    string
+}
+
+func (a *APITest) iPrintTheLastRequestAsCurl() error {
+	if a.lastRequest == nil {
+		return fmt.Errorf("no request has been sent in this scenario")
+	}
+
+	fmt.Println(a.curlCommand())
+
+	return nil
+}
+
+// curlCommand renders the last request as a copy-pasteable curl command,
+// masking secrets when maskSecrets is set.
+func (a *APITest) curlCommand() string {
+	req := a.lastRequest
+	parts := []string{"curl", "-X", req.method, shellQuote(req.url)}
+
+	for _, name := range slices.Sorted(maps.Keys(req.headers)) {
+		for _, value := range req.headers[name] {
+			if a.maskSecrets && isSecret(name) {
+				value = maskedValue
+			}
+			parts = append(parts, "-H", shellQuote(name+": "+value))
+		}
+	}
+
+	if req.body != "" {
+		body := req.body
+		if a.maskSecrets {
+			body = maskJSONSecrets(body)
+		}
+		parts = append(parts, "--data-raw", shellQuote(body))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// withCurl wraps a step handler so its errors end with the curl command for
+// the last request of the scenario, if one was sent.
+func withCurl(a *APITest, handler any) any {
+	fn := reflect.ValueOf(handler)
+	t := fn.Type()
+	if t.NumOut() == 0 || t.Out(t.NumOut()-1) != reflect.TypeFor[error]() {
+		return handler
+	}
+
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		results := fn.Call(args)
+		last := results[len(results)-1]
+		if err, _ := last.Interface().(error); err != nil && a.lastRequest != nil {
+			wrapped := &curlError{err: err, curl: a.curlCommand()}
+			results[len(results)-1] = reflect.ValueOf(error(wrapped))
+		}
+		return results
+	}).Interface()
+}
+
+// curlError is a step error annotated with the request to reproduce it.
+type curlError struct {
+	err  error
+	curl string
+}
+
+func (e *curlError) Error() string {
+	return fmt.Sprintf("%v\n\nReproduce with:\n  %s", e.err, e.curl)
+}
+
+func (e *curlError) Unwrap() error {
+	return e.err
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	return slices.ContainsFunc(secretNames, func(secret string) bool {
+		return strings.Contains(name, secret)
+	})
+}
+
+// maskJSONSecrets replaces the values of secret-looking properties in a JSON
+// body. Other bodies are returned unchanged.
+func maskJSONSecrets(body string) string {
+	var value any
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return body
+	}
+
+	var mask func(v any) any
+	mask = func(v any) any {
+		switch val := v.(type) {
+		case map[string]any:
+			for k, item := range val {
+				if isSecret(k) {
+					val[k] = maskedValue
+				} else {
+					val[k] = mask(item)
+				}
+			}
+		case []any:
+			for i, item := range val {
+				val[i] = mask(item)
+			}
+		}
+		return v
+	}
+
+	return marshalJSON(mask(value))
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
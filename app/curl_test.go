@@ -0,0 +1,66 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCurlCommand(t *testing.T) {
+	server := createTestServer(t, http.StatusCreated, `{"id": 1}`)
+	defer server.Close()
+
+	apiTest := NewAPITest(server.URL)
+	apiTest.headers["Authorization"] = "Bearer ${token}"
+	apiTest.store["token"] = "abc"
+
+	if err := apiTest.iPrintTheLastRequestAsCurl(); err == nil {
+		t.Errorf("Expected an error before any request was sent")
+	}
+
+	if err := apiTest.sendRequest("POST", "/users", `{"name": "O'Brien", "password": "hunter2"}`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "curl -X POST '" + server.URL + "/users' -H 'Authorization: Bearer abc' -H 'Content-Type: application/json' " +
+		`--data-raw '{"name": "O'\''Brien", "password": "hunter2"}'`
+	if got := apiTest.curlCommand(); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	apiTest.maskSecrets = true
+	expected = "curl -X POST '" + server.URL + "/users' -H 'Authorization: ***' -H 'Content-Type: application/json' " +
+		`--data-raw '{"name":"O'\''Brien","password":"***"}'`
+	if got := apiTest.curlCommand(); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestWithCurl(t *testing.T) {
+	server := createTestServer(t, http.StatusInternalServerError, `{}`)
+	defer server.Close()
+
+	apiTest := NewAPITest(server.URL)
+	status := withCurl(apiTest, apiTest.theResponseStatusShouldBe).(func(int) error)
+
+	if err := apiTest.iSendRequestTo("GET", "/health"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err := status(200)
+	if err == nil || !strings.Contains(err.Error(), "expected status 200 but got 500") ||
+		!strings.Contains(err.Error(), "Reproduce with:\n  curl -X GET '"+server.URL+"/health'") {
+		t.Errorf("Expected the error to include the curl command, got %v", err)
+	}
+
+	apiTest.lastRequest = nil
+	if err := status(200); err == nil || strings.Contains(err.Error(), "curl") {
+		t.Errorf("Expected no curl command without a request, got %v", err)
+	}
+
+	debugging := withCurl(apiTest, apiTest.iStartDebugging).(func())
+	debugging()
+	if !apiTest.debug {
+		t.Errorf("Expected handlers without errors to be called")
+	}
+}
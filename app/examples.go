@@ -0,0 +1,446 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	gherkin "github.com/cucumber/gherkin/go/v26"
+	"github.com/cucumber/godog"
+	messages "github.com/cucumber/messages/go/v21"
+	"gopkg.in/yaml.v3"
+)
+
+// examplesTag matches @examples(file=data/rows.csv) tags on Scenario Outlines.
+var examplesTag = regexp.MustCompile(`@examples\(([^)]*)\)`)
+
+// rowColumn is the Examples column identifying the source file and row of
+// each generated example, appended to the scenario name so failures can be
+// traced back to their data.
+const rowColumn = "_row"
+
+// LoadFeatures reads every .feature file under paths. It reports whether any
+// file used @examples(file=...) and was expanded, in which case the returned
+// contents must be run instead of the original files.
+func LoadFeatures(paths []string) ([]godog.Feature, bool, error) {
+	var features []godog.Feature
+	expanded := false
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || filepath.Ext(path) != ".feature" {
+				return nil
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			result, err := ExpandExamples(path, content)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if string(result) != string(content) {
+				expanded = true
+			}
+
+			features = append(features, godog.Feature{Name: path, Contents: result})
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	return features, expanded, nil
+}
+
+// FeatureSource returns the paths and file system to run the features under
+// paths from, serving files that use @examples(file=...) expanded. Line
+// filters such as users.feature:12 refer to the original file; for expanded
+// files they are applied to the served contents instead of being passed on,
+// so that godog reports the file without the expanded line.
+func FeatureSource(paths []string) ([]string, *FeatureFS, error) {
+	roots := make([]string, len(paths))
+	for i, path := range paths {
+		roots[i], _ = featurePathLine(path)
+	}
+
+	features, _, err := LoadFeatures(roots)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fsys := &FeatureFS{contents: map[string][]byte{}, lines: map[string][]int{}}
+	for _, feature := range features {
+		original, err := os.ReadFile(feature.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if string(feature.Contents) == string(original) {
+			continue
+		}
+		name := filepath.Clean(feature.Name)
+		fsys.contents[name] = feature.Contents
+		fsys.lines[name] = sourceLines(feature.Contents, original)
+	}
+
+	var translated []string
+	selected := map[string][]int{}
+	for _, path := range paths {
+		file, line := featurePathLine(path)
+		name := filepath.Clean(file)
+		if _, ok := fsys.lines[name]; !ok || line < 0 {
+			translated = append(translated, path)
+			continue
+		}
+		if _, ok := selected[name]; !ok {
+			translated = append(translated, file)
+		}
+		selected[name] = append(selected[name], line)
+	}
+	for name, lines := range selected {
+		fsys.contents[name] = keepScenarios(fsys.contents[name], fsys.lines[name], lines)
+	}
+
+	return translated, fsys, nil
+}
+
+// keepScenarios blanks every scenario of contents whose original line, looked
+// up in source, is not one of keep. Blanking keeps the remaining scenarios on
+// their lines, so source still applies.
+func keepScenarios(contents []byte, source []int, keep []int) []byte {
+	doc, err := gherkin.ParseGherkinDocument(bytes.NewReader(contents), (&messages.Incrementing{}).NewId)
+	if err != nil || doc.Feature == nil {
+		// godog reports the parse error when it reads the file.
+		return contents
+	}
+
+	// Each block runs from its first tag to the line before the next block;
+	// line is the keyword line of scenarios and 0 for other blocks.
+	type block struct{ start, line int }
+	var blocks []block
+	scenario := func(sc *messages.Scenario) block {
+		return block{start: tagsStart(sc.Location, sc.Tags), line: int(sc.Location.Line)}
+	}
+	for _, child := range doc.Feature.Children {
+		switch {
+		case child.Background != nil:
+			blocks = append(blocks, block{start: int(child.Background.Location.Line)})
+		case child.Scenario != nil:
+			blocks = append(blocks, scenario(child.Scenario))
+		case child.Rule != nil:
+			blocks = append(blocks, block{start: tagsStart(child.Rule.Location, child.Rule.Tags)})
+			for _, ruleChild := range child.Rule.Children {
+				switch {
+				case ruleChild.Background != nil:
+					blocks = append(blocks, block{start: int(ruleChild.Background.Location.Line)})
+				case ruleChild.Scenario != nil:
+					blocks = append(blocks, scenario(ruleChild.Scenario))
+				}
+			}
+		}
+	}
+
+	lines := strings.Split(string(contents), "\n")
+	for i, b := range blocks {
+		if b.line == 0 || slices.Contains(keep, source[b.line]) {
+			continue
+		}
+		end := len(lines)
+		if i+1 < len(blocks) {
+			end = blocks[i+1].start - 1
+		}
+		for n := b.start - 1; n < end; n++ {
+			lines[n] = ""
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// tagsStart returns the first line of a keyword at loc with tags above it.
+func tagsStart(loc *messages.Location, tags []*messages.Tag) int {
+	start := int(loc.Line)
+	for _, tag := range tags {
+		start = min(start, int(tag.Location.Line))
+	}
+	return start
+}
+
+// featurePathLine splits a path such as users.feature:12 into the file and
+// the line to run, which is -1 when the path has no line filter.
+func featurePathLine(path string) (string, int) {
+	if i := strings.LastIndexByte(path, ':'); i > 0 {
+		if line, err := strconv.Atoi(path[i+1:]); err == nil {
+			return path[:i], line
+		}
+	}
+	return path, -1
+}
+
+// FeatureFS serves expanded feature contents by path and reads every other
+// file from disk.
+type FeatureFS struct {
+	contents map[string][]byte
+	// lines maps each line of the expanded contents to its original line.
+	lines map[string][]int
+}
+
+func (f *FeatureFS) Open(name string) (fs.File, error) {
+	contents, ok := f.contents[filepath.Clean(name)]
+	if !ok {
+		return os.Open(name)
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return &featureFile{Reader: bytes.NewReader(contents), info: featureInfo{FileInfo: info, size: int64(len(contents))}}, nil
+}
+
+type featureFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *featureFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *featureFile) Close() error               { return nil }
+
+// featureInfo is the original file's info with the size of the expanded
+// contents.
+type featureInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (i featureInfo) Size() int64 { return i.size }
+
+// ExpandExamples appends an Examples table to every Scenario Outline tagged
+// with @examples(file=...), loading rows from a CSV, JSON or YAML file
+// relative to the feature file.
+func ExpandExamples(featurePath string, content []byte) ([]byte, error) {
+	if !examplesTag.Match(content) {
+		return content, nil
+	}
+
+	lines := strings.Split(string(content), "\n")
+	var result []string
+	var tags []string
+	var docString string
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		result = append(result, line)
+
+		if docString != "" {
+			if strings.HasPrefix(trimmed, docString) {
+				docString = ""
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, `"""`) || strings.HasPrefix(trimmed, "```"):
+			docString = trimmed[:3]
+			continue
+		case strings.HasPrefix(trimmed, "@"):
+			tags = append(tags, trimmed)
+			continue
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case !strings.HasPrefix(trimmed, "Scenario Outline:") && !strings.HasPrefix(trimmed, "Scenario Template:"):
+			tags = nil
+			continue
+		}
+
+		match := examplesTag.FindStringSubmatch(strings.Join(tags, " "))
+		tags = nil
+		if match == nil {
+			continue
+		}
+
+		file, err := parseExamplesTag(match[1])
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(filepath.Dir(featurePath), file)
+		header, rows, err := readExamplesFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.Contains(line, "<"+rowColumn+">") {
+			result[len(result)-1] = line + " [<" + rowColumn + ">]"
+		}
+
+		end := outlineEnd(lines, i+1)
+		result = append(result, lines[i+1:end]...)
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		result = append(result, "", indent+"  Examples: "+file)
+		result = append(result, indent+"    "+tableRow(append([]string{rowColumn}, header...)))
+		for n, row := range rows {
+			id := fmt.Sprintf("%s#%d", file, n+1)
+			result = append(result, indent+"    "+tableRow(append([]string{id}, row...)))
+		}
+
+		i = end - 1
+	}
+
+	return []byte(strings.Join(result, "\n")), nil
+}
+
+// outlineEnd returns the index of the last line belonging to the outline
+// starting at start, excluding trailing blank lines and comments.
+func outlineEnd(lines []string, start int) int {
+	end := len(lines)
+	var docString string
+
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if docString != "" {
+			if strings.HasPrefix(trimmed, docString) {
+				docString = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, `"""`) || strings.HasPrefix(trimmed, "```") {
+			docString = trimmed[:3]
+			continue
+		}
+		if strings.HasPrefix(trimmed, "@") || strings.HasPrefix(trimmed, "Scenario") ||
+			strings.HasPrefix(trimmed, "Example:") || strings.HasPrefix(trimmed, "Rule:") ||
+			strings.HasPrefix(trimmed, "Background:") {
+			end = i
+			break
+		}
+	}
+
+	for end > start {
+		trimmed := strings.TrimSpace(lines[end-1])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		end--
+	}
+
+	return end
+}
+
+func parseExamplesTag(args string) (string, error) {
+	for _, arg := range strings.Split(args, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(arg), "=")
+		if ok && strings.TrimSpace(key) == "file" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("@examples tag requires a file argument, got %q", args)
+}
+
+// readExamplesFile returns the header and rows of a CSV file, or of a JSON
+// or YAML list of objects. Nested values are encoded as JSON.
+func readExamplesFile(path string) ([]string, [][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read examples: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV in %s: %w", path, err)
+		}
+		if len(records) == 0 {
+			return nil, nil, fmt.Errorf("examples file %s is empty", path)
+		}
+		return records[0], records[1:], nil
+	case ".json", ".yaml", ".yml":
+		// YAML is a superset of JSON, and decoding into nodes keeps the
+		// key order of each object for the table header.
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, nil, fmt.Errorf("invalid examples in %s: %w", path, err)
+		}
+		return nodeRows(path, &doc)
+	default:
+		return nil, nil, fmt.Errorf("unsupported examples file type %q", filepath.Ext(path))
+	}
+}
+
+func nodeRows(path string, doc *yaml.Node) ([]string, [][]string, error) {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.SequenceNode {
+		return nil, nil, fmt.Errorf("examples in %s must be a list of objects", path)
+	}
+
+	var header []string
+	columns := map[string]int{}
+	var records []map[string]string
+
+	for _, item := range doc.Content[0].Content {
+		if item.Kind != yaml.MappingNode {
+			return nil, nil, fmt.Errorf("examples in %s must be a list of objects", path)
+		}
+		record := map[string]string{}
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			key := item.Content[j].Value
+			if _, ok := columns[key]; !ok {
+				columns[key] = len(header)
+				header = append(header, key)
+			}
+			value, err := nodeString(item.Content[j+1])
+			if err != nil {
+				return nil, nil, err
+			}
+			record[key] = value
+		}
+		records = append(records, record)
+	}
+
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		row := make([]string, len(header))
+		for j, key := range header {
+			row[j] = record[key]
+		}
+		rows[i] = row
+	}
+
+	return header, rows, nil
+}
+
+func nodeString(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
+
+func tableRow(cells []string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", `\n`)
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = escaper.Replace(cell)
+	}
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
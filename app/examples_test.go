@@ -0,0 +1,181 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cucumber/godog"
+)
+
+func TestExpandExamples(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "data"), 0o755); err != nil {
+		t.Fatalf("Failed to create data dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data", "emails.csv"), []byte("email,status\nnot-an-email,422\n\"a|b@x.com\",422\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data", "users.yaml"), []byte("- name: John\n  age: 30\n- name: Jane\n  tags: [a, b]\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write YAML: %v", err)
+	}
+
+	feature := `Feature: Signup
+
+  @examples(file=data/emails.csv)
+  Scenario Outline: Reject invalid email
+    When I send a "POST" request to "/signup" with payload:
+      """
+      {"email": "<email>"}
+      """
+    Then the response status should be <status>
+
+  @smoke @examples(file=data/users.yaml)
+  Scenario Outline: Create user
+    When I send a "GET" request to "/users/<name>"
+
+  Scenario: Untouched
+    When I send a "GET" request to "/health"
+`
+
+	result, err := ExpandExamples(filepath.Join(dir, "signup.feature"), []byte(feature))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	output := string(result)
+
+	expected := []string{
+		"Scenario Outline: Reject invalid email [<_row>]",
+		"    Then the response status should be <status>\n\n    Examples: data/emails.csv\n      | _row | email | status |",
+		"| data/emails.csv#1 | not-an-email | 422 |",
+		`| data/emails.csv#2 | a\|b@x.com | 422 |`,
+		"| _row | name | age | tags |",
+		"| data/users.yaml#1 | John | 30 |  |",
+		`| data/users.yaml#2 | Jane |  | ["a","b"] |`,
+		"\n\n  Scenario: Untouched",
+	}
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Errorf("Expected output to contain %q, got:\n%s", e, output)
+		}
+	}
+
+	untouched := []byte("Feature: Plain\n  Scenario: Nothing\n")
+	result, err = ExpandExamples("plain.feature", untouched)
+	if err != nil || string(result) != string(untouched) {
+		t.Errorf("Expected feature without @examples to be unchanged, got %q, %v", result, err)
+	}
+
+	_, err = ExpandExamples(filepath.Join(dir, "x.feature"), []byte("Feature: X\n  @examples(file=missing.csv)\n  Scenario Outline: Y\n"))
+	if err == nil {
+		t.Error("Expected error for missing examples file, got nil")
+	}
+}
+
+func TestLoadFeatures(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "plain.feature"), []byte("Feature: Plain\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write feature: %v", err)
+	}
+
+	features, expanded, err := LoadFeatures([]string{dir})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expanded {
+		t.Error("Expected no expansion for plain features")
+	}
+	if len(features) != 1 {
+		t.Errorf("Expected 1 feature, got %d", len(features))
+	}
+}
+
+func TestFeatureSource(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "rows.csv"), []byte("id\n1\n2\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	feature := "Feature: Rows\n\n  @examples(file=rows.csv)\n  Scenario Outline: Get row\n    When I send a \"GET\" request to \"/rows/<id>\"\n\n  Scenario: Health\n    When I send a \"GET\" request to \"/health\"\n"
+	path := filepath.Join(dir, "rows.feature")
+	if err := os.WriteFile(path, []byte(feature), 0o644); err != nil {
+		t.Fatalf("Failed to write feature: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "plain.feature"), []byte("Feature: Plain\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write feature: %v", err)
+	}
+
+	paths, fsys, err := FeatureSource([]string{dir, path + ":7", filepath.Join(dir, "plain.feature") + ":1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The filter on the expanded file is applied to its contents.
+	expected := []string{dir, path, filepath.Join(dir, "plain.feature") + ":1"}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("Expected path %q, got %q", expected[i], paths[i])
+		}
+	}
+
+	contents, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		t.Fatalf("Expected expanded feature to be readable, got %v", err)
+	}
+	lines := strings.Split(string(contents), "\n")
+	if len(lines) < 12 || strings.TrimSpace(lines[11]) != "Scenario: Health" {
+		t.Errorf("Expected line 12 to be the Health scenario, got:\n%s", contents)
+	}
+	if strings.Contains(string(contents), "Scenario Outline") || strings.Contains(string(contents), "rows.csv#2") {
+		t.Errorf("Expected the outline to be left out by the line filter, got:\n%s", contents)
+	}
+
+	plain, err := fs.ReadFile(fsys, filepath.Join(dir, "plain.feature"))
+	if err != nil || string(plain) != "Feature: Plain\n" {
+		t.Errorf("Expected plain feature to be read from disk, got %q, %v", plain, err)
+	}
+}
+
+func TestFeatureSourceReportsSourceLines(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "rows.csv"), []byte("id\n1\n2\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	feature := "Feature: Rows\n\n  @examples(file=rows.csv)\n  Scenario Outline: Get row\n    Given row <id> passes\n\n  Scenario: Plain\n    Given it fails\n"
+	path := filepath.Join(dir, "rows.feature")
+	if err := os.WriteFile(path, []byte(feature), 0o644); err != nil {
+		t.Fatalf("Failed to write feature: %v", err)
+	}
+
+	for _, filter := range []string{"", ":7"} {
+		paths, fsys, err := FeatureSource([]string{path + filter})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		var out bytes.Buffer
+		godog.Format("rbdd-lines", "", fsys.Formatter())
+		suite := godog.TestSuite{
+			ScenarioInitializer: func(ctx *godog.ScenarioContext) {
+				ctx.Step(`^row (\d+) passes$`, func(int) error { return nil })
+				ctx.Step(`^it fails$`, func() error { return errors.New("failed") })
+			},
+			Options: &godog.Options{Format: "rbdd-lines,pretty", Paths: paths, FS: fsys, Output: &out, NoColors: true},
+		}
+		suite.Run()
+
+		// The Plain scenario and its step are reported at lines 7 and 8 of
+		// the file, not below the generated rows.
+		for _, location := range []string{path + ":7", path + ":8"} {
+			if !strings.Contains(out.String(), location+"\n") {
+				t.Errorf("Expected %q to report %s, got:\n%s", filter, location, out.String())
+			}
+		}
+		if strings.Contains(out.String(), path+":12") || strings.Contains(out.String(), path+":13") {
+			t.Errorf("Expected %q to report no expanded lines, got:\n%s", filter, out.String())
+		}
+	}
+}
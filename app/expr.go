@@ -0,0 +1,675 @@
+package app
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// undefinedVarError is returned when an expression references a variable
+// that is not present in the store.
+type undefinedVarError struct {
+	name string
+}
+
+func (e *undefinedVarError) Error() string {
+	return fmt.Sprintf("undefined variable %q", e.name)
+}
+
+// missingValueError is returned when a field or index cannot be read from a
+// value, such as a missing key or an index out of range.
+type missingValueError struct {
+	msg string
+}
+
+func (e *missingValueError) Error() string {
+	return e.msg
+}
+
+// evalExpr evaluates the contents of a ${...} placeholder. Besides plain
+// variable lookups it supports nested access (user.address.city, items[0].id),
+// defaults (token:-anon), environment lookups (env.API_KEY), function calls
+// and arithmetic.
+func (a *APITest) evalExpr(expr string) (any, error) {
+	expr = strings.TrimSpace(expr)
+
+	// Keys are looked up verbatim first so existing variable names that
+	// happen to contain operators keep working.
+	if val, ok := a.store[expr]; ok {
+		return val, nil
+	}
+
+	if left, right, ok := splitDefault(expr); ok {
+		val, err := a.evalExpr(left)
+		if err == nil {
+			return val, nil
+		}
+		// Only failed lookups fall back to the default; other errors,
+		// such as a failing function call, are reported.
+		_, undefined := err.(*undefinedVarError)
+		_, missing := err.(*missingValueError)
+		if !undefined && !missing {
+			return nil, err
+		}
+		if val, err := a.evalExpr(right); err == nil {
+			return val, nil
+		}
+		return unquote(strings.TrimSpace(right)), nil
+	}
+
+	p := &exprParser{api: a, tokens: tokenize(expr)}
+	val, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in expression %q", p.tokens[p.pos].text, expr)
+	}
+	return val, nil
+}
+
+// splitDefault splits an expression on the first ":-" found outside of
+// quotes and parentheses.
+func splitDefault(expr string) (string, string, bool) {
+	depth := 0
+	var quote rune
+	for i, r := range expr {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == ':' && depth == 0 && strings.HasPrefix(expr[i:], ":-"):
+			return expr[:i], expr[i+2:], true
+		}
+	}
+	return "", "", false
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// formatValue renders a value for substitution into plain text.
+func formatValue(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case time.Duration:
+		return v.String()
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+type tokenKind int
+
+const (
+	tokNumber tokenKind = iota
+	tokDuration
+	tokString
+	tokIdent
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(s string) []token {
+	var tokens []token
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				sb.WriteRune(runes[j])
+				j++
+			}
+			tokens = append(tokens, token{tokString, sb.String()})
+			i = j + 1
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			kind := tokNumber
+			// A number directly followed by letters is a duration such as
+			// 1h, 30m, 500ms or 1h30m.
+			if j < len(runes) && unicode.IsLetter(runes[j]) {
+				kind = tokDuration
+				for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '.') {
+					j++
+				}
+			}
+			tokens = append(tokens, token{kind, string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[i:j])})
+			i = j
+		default:
+			tokens = append(tokens, token{tokPunct, string(r)})
+			i++
+		}
+	}
+	return tokens
+}
+
+type exprParser struct {
+	api    *APITest
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek(text string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokPunct && p.tokens[p.pos].text == text
+}
+
+func (p *exprParser) expect(text string) error {
+	if !p.peek(text) {
+		return fmt.Errorf("expected %q in expression", text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *exprParser) parseAdditive() (any, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("+") || p.peek("-") {
+		op := p.tokens[p.pos].text
+		p.pos++
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = applyOperator(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseMultiplicative() (any, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("*") || p.peek("/") || p.peek("%") {
+		op := p.tokens[p.pos].text
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = applyOperator(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (any, error) {
+	if p.peek("-") {
+		p.pos++
+		val, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return applyOperator("-", 0.0, val)
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix parses a primary expression followed by any number of
+// .field and [index] accessors. Leading identifier chains are resolved
+// against the store using the longest matching key, so a variable stored as
+// "mock.payments.url" is found before "mock" is navigated.
+func (p *exprParser) parsePostfix() (any, error) {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokIdent &&
+		!(p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].kind == tokPunct && p.tokens[p.pos+1].text == "(") {
+		return p.parseVariable()
+	}
+
+	val, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseAccessors(val)
+}
+
+func (p *exprParser) parseVariable() (any, error) {
+	path := []string{p.tokens[p.pos].text}
+	p.pos++
+	for p.peek(".") && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].kind == tokIdent {
+		path = append(path, p.tokens[p.pos+1].text)
+		p.pos += 2
+	}
+
+	var val any
+	found := false
+	for n := len(path); n > 0; n-- {
+		if v, ok := p.api.store[strings.Join(path[:n], ".")]; ok {
+			val, found = v, true
+			path = path[n:]
+			break
+		}
+	}
+
+	if !found {
+		if path[0] != "env" || len(path) < 2 {
+			return nil, &undefinedVarError{name: strings.Join(path, ".")}
+		}
+		env, ok := os.LookupEnv(path[1])
+		if !ok {
+			return nil, &undefinedVarError{name: "env." + path[1]}
+		}
+		val, path = env, path[2:]
+	}
+
+	for _, field := range path {
+		next, err := access(val, field)
+		if err != nil {
+			return nil, err
+		}
+		val = next
+	}
+	return p.parseAccessors(val)
+}
+
+func (p *exprParser) parseAccessors(val any) (any, error) {
+	for {
+		switch {
+		case p.peek("."):
+			p.pos++
+			if p.pos >= len(p.tokens) || (p.tokens[p.pos].kind != tokIdent && p.tokens[p.pos].kind != tokNumber) {
+				return nil, fmt.Errorf("expected field name after '.'")
+			}
+			next, err := access(val, p.tokens[p.pos].text)
+			if err != nil {
+				return nil, err
+			}
+			val = next
+			p.pos++
+		case p.peek("["):
+			p.pos++
+			key, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			next, err := access(val, formatValue(key))
+			if err != nil {
+				return nil, err
+			}
+			val = next
+		default:
+			return val, nil
+		}
+	}
+}
+
+func (p *exprParser) parsePrimary() (any, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case tokNumber:
+		return strconv.ParseFloat(tok.text, 64)
+	case tokDuration:
+		return parseDuration(tok.text)
+	case tokString:
+		return tok.text, nil
+	case tokIdent:
+		return p.parseCall(tok.text)
+	}
+
+	if tok.text == "(" {
+		val, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return val, p.expect(")")
+	}
+
+	return nil, fmt.Errorf("unexpected %q in expression", tok.text)
+}
+
+func (p *exprParser) parseCall(name string) (any, error) {
+	fn, ok := exprFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var args []any
+	for !p.peek(")") {
+		arg, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if !p.peek(",") {
+			break
+		}
+		p.pos++
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	return fn(p.api, args)
+}
+
+// parseDuration extends time.ParseDuration with a "d" unit for days.
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// access returns the named field or index of a map, slice or JSON string.
+func access(val any, key string) (any, error) {
+	if s, ok := val.(string); ok {
+		trimmed := strings.TrimSpace(s)
+		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			var decoded any
+			if err := json.Unmarshal([]byte(trimmed), &decoded); err == nil {
+				val = decoded
+			}
+		}
+	}
+
+	switch v := val.(type) {
+	case map[string]any:
+		field, ok := v[key]
+		if !ok {
+			return nil, &missingValueError{msg: fmt.Sprintf("key %q not found", key)}
+		}
+		return field, nil
+	case []any:
+		i, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, &missingValueError{msg: fmt.Sprintf("invalid index %q", key)}
+		}
+		if i < 0 {
+			i += len(v)
+		}
+		if i < 0 || i >= len(v) {
+			return nil, &missingValueError{msg: fmt.Sprintf("index %d out of range", i)}
+		}
+		return v[i], nil
+	default:
+		return nil, &missingValueError{msg: fmt.Sprintf("cannot access %q on %T", key, val)}
+	}
+}
+
+func toNumber(val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func applyOperator(op string, left, right any) (any, error) {
+	switch l := left.(type) {
+	case time.Time:
+		switch r := right.(type) {
+		case time.Duration:
+			switch op {
+			case "+":
+				return l.Add(r), nil
+			case "-":
+				return l.Add(-r), nil
+			}
+		case time.Time:
+			if op == "-" {
+				return l.Sub(r), nil
+			}
+		}
+	case time.Duration:
+		if r, ok := right.(time.Duration); ok {
+			switch op {
+			case "+":
+				return l + r, nil
+			case "-":
+				return l - r, nil
+			}
+		}
+		if r, ok := right.(time.Time); ok && op == "+" {
+			return r.Add(l), nil
+		}
+		if r, ok := toNumber(right); ok {
+			switch op {
+			case "*":
+				return time.Duration(float64(l) * r), nil
+			case "/":
+				return time.Duration(float64(l) / r), nil
+			}
+		}
+	}
+
+	ln, lok := toNumber(left)
+	rn, rok := toNumber(right)
+	if lok && rok {
+		switch op {
+		case "+":
+			return ln + rn, nil
+		case "-":
+			return ln - rn, nil
+		case "*":
+			return ln * rn, nil
+		case "/":
+			if rn == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return ln / rn, nil
+		case "%":
+			return math.Mod(ln, rn), nil
+		}
+	}
+
+	if op == "+" {
+		return formatValue(left) + formatValue(right), nil
+	}
+
+	return nil, fmt.Errorf("unsupported operation %v %s %v", left, op, right)
+}
+
+var timeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC822":      time.RFC822,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+func stringArg(name string, args []any) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("%s expects 1 argument, got %d", name, len(args))
+	}
+	return formatValue(args[0]), nil
+}
+
+// exprFuncs are the functions available in expressions. Random values come
+// from the scenario's faker so that --seed reproduces them.
+var exprFuncs map[string]func(a *APITest, args []any) (any, error)
+
+func init() {
+	exprFuncs = map[string]func(a *APITest, args []any) (any, error){
+		"uuid": func(a *APITest, args []any) (any, error) {
+			return a.faker.UUID(), nil
+		},
+		"now": func(a *APITest, args []any) (any, error) {
+			now := time.Now().UTC()
+			if len(args) == 0 {
+				return now, nil
+			}
+			return formatTime(now, formatValue(args[0])), nil
+		},
+		"format": func(a *APITest, args []any) (any, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("format expects 2 arguments, got %d", len(args))
+			}
+			t, ok := args[0].(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("format expects a time, got %T", args[0])
+			}
+			return formatTime(t, formatValue(args[1])), nil
+		},
+		"base64": func(a *APITest, args []any) (any, error) {
+			s, err := stringArg("base64", args)
+			return base64.StdEncoding.EncodeToString([]byte(s)), err
+		},
+		"base64decode": func(a *APITest, args []any) (any, error) {
+			s, err := stringArg("base64decode", args)
+			if err != nil {
+				return nil, err
+			}
+			decoded, err := base64.StdEncoding.DecodeString(s)
+			return string(decoded), err
+		},
+		"sha256": func(a *APITest, args []any) (any, error) {
+			s, err := stringArg("sha256", args)
+			sum := sha256.Sum256([]byte(s))
+			return hex.EncodeToString(sum[:]), err
+		},
+		"md5": func(a *APITest, args []any) (any, error) {
+			s, err := stringArg("md5", args)
+			sum := md5.Sum([]byte(s))
+			return hex.EncodeToString(sum[:]), err
+		},
+		"upper": func(a *APITest, args []any) (any, error) {
+			s, err := stringArg("upper", args)
+			return strings.ToUpper(s), err
+		},
+		"lower": func(a *APITest, args []any) (any, error) {
+			s, err := stringArg("lower", args)
+			return strings.ToLower(s), err
+		},
+		"trim": func(a *APITest, args []any) (any, error) {
+			s, err := stringArg("trim", args)
+			return strings.TrimSpace(s), err
+		},
+		"urlencode": func(a *APITest, args []any) (any, error) {
+			s, err := stringArg("urlencode", args)
+			return url.QueryEscape(s), err
+		},
+		"len": func(a *APITest, args []any) (any, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("len expects 1 argument, got %d", len(args))
+			}
+			switch v := args[0].(type) {
+			case []any:
+				return float64(len(v)), nil
+			case map[string]any:
+				return float64(len(v)), nil
+			case string:
+				// Arrays and objects stored from responses are raw JSON.
+				var decoded any
+				if json.Unmarshal([]byte(v), &decoded) == nil {
+					switch d := decoded.(type) {
+					case []any:
+						return float64(len(d)), nil
+					case map[string]any:
+						return float64(len(d)), nil
+					}
+				}
+				return float64(len([]rune(v))), nil
+			default:
+				return float64(len(formatValue(v))), nil
+			}
+		},
+	}
+}
+
+// formatTime formats t using a named layout (RFC3339, DateOnly, ...),
+// "unix"/"unixmilli" for epoch values, or a Go reference layout.
+func formatTime(t time.Time, layout string) string {
+	switch layout {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unixmilli":
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	if named, ok := timeLayouts[layout]; ok {
+		layout = named
+	}
+	return t.Format(layout)
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvalExpr(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	apiTest.strict = true
+	apiTest.store["user"] = map[string]any{
+		"name":    "john",
+		"address": map[string]any{"city": "Brisbane"},
+	}
+	apiTest.store["items"] = `[{"id": 7}, {"id": 8}]`
+	apiTest.store["count"] = float64(3)
+	apiTest.store["o2"] = `{"a": 1}`
+	apiTest.store["mock.payments.url"] = "http://127.0.0.1:1234"
+	t.Setenv("RBDD_EXPR_TEST", "secret")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"${user.address.city}", "Brisbane"},
+		{"${items[0].id}", "7"},
+		{"${items[-1].id}", "8"},
+		{"${missing:-anon}", "anon"},
+		{"${count:-0}", "3"},
+		{"${o2.b:-none}", "none"},
+		{"${user.address.zip:-unknown}", "unknown"},
+		{"${items[5].id:-none}", "none"},
+		{"${count.value:-none}", "none"},
+		{"${env.RBDD_EXPR_TEST}", "secret"},
+		{"${upper(user.name)}", "JOHN"},
+		{"${len(items)}", "2"},
+		{"${count * 2 + 1}", "7"},
+		{"${(count + 1) / 2}", "2"},
+		{"${base64(\"hi\")}", "aGk="},
+		{"${sha256(\"abc\")}", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"${mock.payments.url}/charges", "http://127.0.0.1:1234/charges"},
+		{"${1000000}", "1000000"},
+	}
+
+	for _, test := range tests {
+		result, err := apiTest.replaceVars(test.input)
+		if err != nil {
+			t.Errorf("For %q, expected no error, got %v", test.input, err)
+		}
+		if result != test.expected {
+			t.Errorf("For %q, expected %q, got %q", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestEvalExprFunctions(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+
+	id, err := apiTest.replaceVars("${uuid()}")
+	if err != nil || len(id) != 36 {
+		t.Errorf("Expected a UUID, got %q", id)
+	}
+
+	now, err := apiTest.replaceVars(`${now("RFC3339")}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339, now); err != nil {
+		t.Errorf("Expected RFC3339 timestamp, got %q", now)
+	}
+
+	later, err := apiTest.evalExpr("now() + 1h")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if diff := time.Until(later.(time.Time)); diff < 59*time.Minute || diff > time.Hour {
+		t.Errorf("Expected time one hour from now, got %v", later)
+	}
+
+	if _, err := apiTest.evalExpr("nosuchfunc()"); err == nil {
+		t.Error("Expected error for unknown function, got nil")
+	}
+
+	_, err = apiTest.evalExpr("unknown_var.field")
+	if err == nil || !strings.Contains(err.Error(), "unknown_var") {
+		t.Errorf("Expected undefined variable error, got %v", err)
+	}
+}
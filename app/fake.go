@@ -1,11 +1,161 @@
 package app
 
 import (
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/cucumber/godog"
 )
 
+// repeatPattern marks an array template whose second element is generated
+// N times, or between min and max times: ["{repeat:3}", {...}].
+var repeatPattern = regexp.MustCompile(`^\{repeat:(\d+)(?:,(\d+))?\}$`)
+
+// uniqueSuffix marks a data specification whose values must not repeat for
+// the same variable during the run, e.g. "email={email}!unique".
+const uniqueSuffix = "!unique"
+
+// uniqueAttempts bounds how often a unique value is regenerated before
+// giving up.
+const uniqueAttempts = 100
+
+// tagPattern matches a single gofakeit tag such as {email} or {number:1,10}.
+var tagPattern = regexp.MustCompile(`\{([^{}:]+)(?::([^{}]*))?\}`)
+
 func (a *APITest) iGenerateFakeData(dataSpec string) error {
+	return a.generateFakeData(dataSpec, a.locale)
+}
+
+func (a *APITest) iGenerateFakeDataInLocale(locale, dataSpec string) error {
+	return a.generateFakeData(dataSpec, locale)
+}
+
+func (a *APITest) iGenerateAFakeFromJSONTemplate(variable, template string) error {
+	rendered, err := a.renderJSON(template)
+	if err != nil {
+		return err
+	}
+
+	var parsed any
+	if err := json.Unmarshal([]byte(rendered), &parsed); err != nil {
+		return fmt.Errorf("invalid JSON template: %w", err)
+	}
+
+	value, err := a.generateTemplate(parsed)
+	if err != nil {
+		return fmt.Errorf("error generating fake %s: %w", variable, err)
+	}
+	a.store[variable] = value
+
+	if a.debug {
+		fmt.Printf("Generated fake %s: %v\n", variable, formatValue(value))
+	}
+
+	return nil
+}
+
+func (a *APITest) iGenerateFakeDataMatchingSchemaAs(path, variable string) error {
+	path, err := a.replaceVars(path)
+	if err != nil {
+		return err
+	}
+
+	schema, root, err := loadSchema(path)
+	if err != nil {
+		return err
+	}
+
+	generator := &schemaGenerator{api: a, root: root, locale: a.locale}
+	value, err := generator.generate(schema, variable, 0)
+	if err != nil {
+		return fmt.Errorf("error generating fake %s from %s: %w", variable, path, err)
+	}
+	a.store[variable] = value
+
+	if a.debug {
+		fmt.Printf("Generated fake %s from %s: %v\n", variable, path, formatValue(value))
+	}
+
+	return nil
+}
+
+// generateTemplate replaces gofakeit tags in every string of a decoded JSON
+// template and expands {repeat:N} arrays.
+func (a *APITest) generateTemplate(node any) (any, error) {
+	switch v := node.(type) {
+	case string:
+		if !tagPattern.MatchString(v) {
+			return v, nil
+		}
+		return a.generateTyped(v, a.locale)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		// Sorted so seeded runs consume random values in a stable order.
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			value, err := a.generateTemplate(v[k])
+			if err != nil {
+				return nil, fmt.Errorf("in key %q: %w", k, err)
+			}
+			result[k] = value
+		}
+		return result, nil
+	case []any:
+		if len(v) > 0 {
+			if s, ok := v[0].(string); ok {
+				if match := repeatPattern.FindStringSubmatch(s); match != nil {
+					return a.generateRepeat(match, v[1:])
+				}
+			}
+		}
+		result := make([]any, len(v))
+		for i, item := range v {
+			value, err := a.generateTemplate(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
+
+func (a *APITest) generateRepeat(match []string, rest []any) (any, error) {
+	if len(rest) != 1 {
+		return nil, fmt.Errorf("%s must be followed by exactly one element to repeat", match[0])
+	}
+
+	count, _ := strconv.Atoi(match[1])
+	if match[2] != "" {
+		upper, _ := strconv.Atoi(match[2])
+		count = a.faker.IntRange(count, upper)
+	}
+
+	result := make([]any, count)
+	for i := range result {
+		value, err := a.generateTemplate(rest[0])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+func (a *APITest) generateFakeData(dataSpec, locale string) error {
+	if _, _, err := localeTag(locale, ""); err != nil {
+		return err
+	}
+
 	pairs := splitByCommaOutsideBrackets(dataSpec)
 
 	for _, pair := range pairs {
@@ -15,13 +165,19 @@ func (a *APITest) iGenerateFakeData(dataSpec string) error {
 		}
 
 		varName := strings.TrimSpace(parts[0])
-		pattern := strings.TrimSpace(parts[1])
+		pattern, unique := strings.CutSuffix(strings.TrimSpace(parts[1]), uniqueSuffix)
 
-		value, err := generateFromTag(pattern)
+		value, err := a.generate(pattern, locale)
 		if err != nil {
 			return fmt.Errorf("error generating fake data for %s: %w", varName, err)
 		}
 
+		if unique {
+			if value, err = a.generateUnique(varName, pattern, locale, value); err != nil {
+				return err
+			}
+		}
+
 		a.store[varName] = value
 	}
 
@@ -31,3 +187,141 @@ func (a *APITest) iGenerateFakeData(dataSpec string) error {
 
 	return nil
 }
+
+// generate expands the gofakeit tags in pattern. Custom generators and tags
+// overridden by the locale are generated first and swapped in after gofakeit
+// has run, so their values are not reinterpreted as patterns.
+func (a *APITest) generate(pattern, locale string) (string, error) {
+	return a.expandTags(pattern, locale, 0)
+}
+
+func (a *APITest) expandTags(pattern, locale string, depth int) (string, error) {
+	if depth > maxGeneratorDepth {
+		return "", fmt.Errorf("generators nested too deeply in %q", pattern)
+	}
+
+	var values []string
+	var tagErr error
+
+	pattern = tagPattern.ReplaceAllStringFunc(pattern, func(tag string) string {
+		if tagErr != nil {
+			return tag
+		}
+
+		name := tagPattern.FindStringSubmatch(tag)[1]
+		if gen, ok := a.generators[strings.ToLower(name)]; ok {
+			value, err := a.runGenerator(gen, locale, depth)
+			if err != nil {
+				tagErr = fmt.Errorf("generator %q: %w", name, err)
+				return tag
+			}
+			values = append(values, value)
+			return fmt.Sprintf("\x00%d\x00", len(values)-1)
+		}
+
+		gen, ok, err := localeTag(locale, name)
+		if err != nil {
+			tagErr = err
+		}
+		if !ok {
+			return tag
+		}
+		values = append(values, gen(a.faker))
+		return fmt.Sprintf("\x00%d\x00", len(values)-1)
+	})
+	if tagErr != nil {
+		return "", tagErr
+	}
+
+	result, err := generateFromTag(a.faker, pattern)
+	if err != nil {
+		return "", err
+	}
+
+	for i, value := range values {
+		result = strings.Replace(result, fmt.Sprintf("\x00%d\x00", i), value, 1)
+	}
+
+	return result, nil
+}
+
+// generateTyped expands pattern like generate, but keeps the type of tags
+// producing numbers or booleans when the pattern is a single tag.
+func (a *APITest) generateTyped(pattern, locale string) (any, error) {
+	value, err := a.generate(pattern, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	match := tagPattern.FindStringSubmatch(pattern)
+	if match == nil || match[0] != pattern {
+		return value, nil
+	}
+	if info := gofakeit.GetFuncLookup(strings.ToLower(match[1])); info != nil {
+		switch {
+		case info.Output == "bool":
+			if b, err := strconv.ParseBool(value); err == nil {
+				return b, nil
+			}
+		case strings.HasPrefix(info.Output, "int") || strings.HasPrefix(info.Output, "uint") || strings.HasPrefix(info.Output, "float"):
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				return n, nil
+			}
+		}
+	}
+
+	return value, nil
+}
+
+// generateUnique regenerates value until it has not been produced for
+// varName before during this run.
+func (a *APITest) generateUnique(varName, pattern, locale, value string) (string, error) {
+	if a.unique == nil {
+		a.unique = map[string]map[string]struct{}{}
+	}
+	seen, ok := a.unique[varName]
+	if !ok {
+		seen = map[string]struct{}{}
+		a.unique[varName] = seen
+	}
+
+	for attempt := 1; ; attempt++ {
+		if _, exists := seen[value]; !exists {
+			seen[value] = struct{}{}
+			return value, nil
+		}
+		if attempt == uniqueAttempts {
+			return "", fmt.Errorf("could not generate a unique value for %s from %q after %d attempts (%d values already used)",
+				varName, pattern, uniqueAttempts, len(seen))
+		}
+
+		var err error
+		if value, err = a.generate(pattern, locale); err != nil {
+			return "", fmt.Errorf("error generating fake data for %s: %w", varName, err)
+		}
+	}
+}
+
+// reseed derives a scenario's fake data seed from the run seed and the
+// scenario's location, name and steps, so values stay stable when scenarios
+// are reordered, filtered or run in parallel. A zero run seed keeps fake
+// data random.
+func (a *APITest) reseed(sc *godog.Scenario) {
+	if a.seed == 0 {
+		return
+	}
+	a.faker = gofakeit.New(scenarioSeed(a.seed, sc))
+}
+
+func scenarioSeed(seed uint64, sc *godog.Scenario) uint64 {
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, seed)
+	h.Write([]byte(sc.Uri))
+	h.Write([]byte(sc.Name))
+	for _, step := range sc.Steps {
+		h.Write([]byte(step.Text))
+	}
+
+	// gofakeit treats a zero seed as "random".
+	return max(h.Sum64(), 1)
+}
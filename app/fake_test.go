@@ -1,6 +1,15 @@
 package app
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cucumber/godog"
+)
 
 func TestIGenerateFakeData(t *testing.T) {
 	apiTest := NewAPITest("https://example.com")
@@ -31,3 +40,190 @@ func TestIGenerateFakeData(t *testing.T) {
 		t.Errorf("Expected 'test' to be stored with value '{invalidtag}', got %v", stored)
 	}
 }
+
+func TestReseed(t *testing.T) {
+	generate := func(seed uint64, name string) string {
+		apiTest := NewAPITest("https://example.com")
+		apiTest.seed = seed
+		apiTest.reseed(&godog.Scenario{Uri: "features/users.feature", Name: name})
+		if err := apiTest.iGenerateFakeData("seeded_email={email}"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return apiTest.store["seeded_email"].(string)
+	}
+
+	first := generate(42, "Create user")
+	if second := generate(42, "Create user"); first != second {
+		t.Errorf("Expected same seed to produce %q, got %q", first, second)
+	}
+	if other := generate(42, "Delete user"); first == other {
+		t.Errorf("Expected different scenarios to produce different values, both got %q", first)
+	}
+	if other := generate(7, "Create user"); first == other {
+		t.Errorf("Expected different seeds to produce different values, both got %q", first)
+	}
+
+	uuid := func(seed uint64) string {
+		apiTest := NewAPITest("https://example.com")
+		apiTest.seed = seed
+		apiTest.reseed(&godog.Scenario{Uri: "features/users.feature", Name: "Create user"})
+		id, err := apiTest.replaceVars("${uuid()}")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return id
+	}
+	if first, second := uuid(42), uuid(42); first != second {
+		t.Errorf("Expected same seed to produce the same uuid(), got %q and %q", first, second)
+	}
+}
+
+func TestIGenerateFakeDataInLocale(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+
+	err := apiTest.iGenerateFakeDataInLocale("de", "de_city={city}, de_zip={zip}, de_phone={phoneformatted}, de_label=Kunde {lastname} #1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !slices.Contains(locales["de"].cities, apiTest.store["de_city"].(string)) {
+		t.Errorf("Expected a German city, got %v", apiTest.store["de_city"])
+	}
+	if zip := apiTest.store["de_zip"].(string); len(zip) != 5 {
+		t.Errorf("Expected a 5 digit postcode, got %q", zip)
+	}
+	if phone := apiTest.store["de_phone"].(string); !strings.HasPrefix(phone, "+49 ") {
+		t.Errorf("Expected a German phone number, got %q", phone)
+	}
+	label := apiTest.store["de_label"].(string)
+	if !strings.HasPrefix(label, "Kunde ") || strings.Contains(label, "{") || strings.Contains(label, "#") {
+		t.Errorf("Expected a fully generated label, got %q", label)
+	}
+
+	err = apiTest.iGenerateFakeDataInLocale("xx", "city={city}")
+	if err == nil {
+		t.Error("Expected error for unsupported locale, got nil")
+	}
+}
+
+func TestIGenerateFakeDataUnique(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+
+	seen := map[string]bool{}
+	for range 20 {
+		if err := apiTest.iGenerateFakeData("unique_email={email}!unique"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		email := apiTest.store["unique_email"].(string)
+		if seen[email] {
+			t.Fatalf("Expected unique email, got duplicate %q", email)
+		}
+		seen[email] = true
+	}
+
+	if err := apiTest.iGenerateFakeData("flag={bool}!unique"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iGenerateFakeData("flag={bool}!unique"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	err := apiTest.iGenerateFakeData("flag={bool}!unique")
+	if err == nil || !strings.Contains(err.Error(), "unique value for flag") {
+		t.Errorf("Expected exhausted unique error, got %v", err)
+	}
+}
+
+func TestIGenerateAFakeFromJSONTemplate(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	apiTest.store["tenant"] = "acme"
+
+	err := apiTest.iGenerateAFakeFromJSONTemplate("fake_user", `{
+		"email": "{email}",
+		"age": "{number:18,65}",
+		"active": "{bool}",
+		"tenant": "${tenant}",
+		"label": "user-{lettern:4}",
+		"addresses": ["{repeat:3}", {"city": "{city}"}]
+	}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	user, ok := apiTest.store["fake_user"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected object to be stored, got %T", apiTest.store["fake_user"])
+	}
+	if email, ok := user["email"].(string); !ok || !strings.Contains(email, "@") {
+		t.Errorf("Expected generated email, got %v", user["email"])
+	}
+	if age, ok := user["age"].(float64); !ok || age < 18 || age > 65 {
+		t.Errorf("Expected numeric age between 18 and 65, got %v", user["age"])
+	}
+	if _, ok := user["active"].(bool); !ok {
+		t.Errorf("Expected boolean active, got %T", user["active"])
+	}
+	if user["tenant"] != "acme" {
+		t.Errorf("Expected tenant from store, got %v", user["tenant"])
+	}
+	if label, ok := user["label"].(string); !ok || len(label) != 9 {
+		t.Errorf("Expected generated label, got %v", user["label"])
+	}
+	if addresses, ok := user["addresses"].([]any); !ok || len(addresses) != 3 {
+		t.Errorf("Expected 3 addresses, got %v", user["addresses"])
+	}
+
+	err = apiTest.iGenerateAFakeFromJSONTemplate("broken", `["{repeat:2}"]`)
+	if err == nil {
+		t.Error("Expected error for repeat without element, got nil")
+	}
+}
+
+func TestIGenerateFakeDataMatchingSchemaAs(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	path := filepath.Join(t.TempDir(), "order.json")
+	schema := `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"email": {"type": "string"},
+			"quantity": {"type": "integer", "minimum": 1, "maximum": 5},
+			"status": {"enum": ["pending", "paid"]},
+			"sku": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]{4}$"},
+			"items": {"type": "array", "minItems": 2, "maxItems": 2, "items": {"$ref": "#/definitions/item"}}
+		},
+		"definitions": {
+			"item": {"type": "object", "properties": {"price": {"type": "number", "minimum": 1, "maximum": 10}}}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(schema), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	if err := apiTest.iGenerateFakeDataMatchingSchemaAs(path, "order"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	order := apiTest.store["order"].(map[string]any)
+	if id, _ := order["id"].(string); len(id) != 36 {
+		t.Errorf("Expected uuid id, got %v", order["id"])
+	}
+	if email, _ := order["email"].(string); !strings.Contains(email, "@") {
+		t.Errorf("Expected email derived from the field name, got %v", order["email"])
+	}
+	if qty, ok := order["quantity"].(float64); !ok || qty < 1 || qty > 5 {
+		t.Errorf("Expected quantity between 1 and 5, got %v", order["quantity"])
+	}
+	if status := order["status"]; status != "pending" && status != "paid" {
+		t.Errorf("Expected status from enum, got %v", status)
+	}
+	if sku, _ := order["sku"].(string); !regexp.MustCompile(`^[A-Z]{3}-[0-9]{4}$`).MatchString(sku) {
+		t.Errorf("Expected sku matching pattern, got %v", order["sku"])
+	}
+	items, ok := order["items"].([]any)
+	if !ok || len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %v", order["items"])
+	}
+	if price, ok := items[0].(map[string]any)["price"].(float64); !ok || price < 1 || price > 10 {
+		t.Errorf("Expected item price between 1 and 10, got %v", items[0])
+	}
+}
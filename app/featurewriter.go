@@ -0,0 +1,79 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// scenarioWriter writes the steps of a generated scenario, replacing a
+// keyword that repeats the previous step's with And.
+type scenarioWriter struct {
+	sb       *strings.Builder
+	previous string
+}
+
+func newScenarioWriter(sb *strings.Builder, name string) *scenarioWriter {
+	fmt.Fprintf(sb, "  Scenario: %s\n", strings.TrimSpace(name))
+	return &scenarioWriter{sb: sb}
+}
+
+func (w *scenarioWriter) step(keyword, format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	if keyword == w.previous {
+		fmt.Fprintf(w.sb, "    And %s\n", text)
+	} else {
+		fmt.Fprintf(w.sb, "    %s %s\n", keyword, text)
+	}
+	w.previous = keyword
+}
+
+func (w *scenarioWriter) docString(content string) {
+	w.sb.WriteString("      \"\"\"\n")
+	for _, line := range strings.Split(content, "\n") {
+		fmt.Fprintf(w.sb, "      %s\n", line)
+	}
+	w.sb.WriteString("      \"\"\"\n")
+}
+
+// ImportResult holds the feature files converted from another tool, keyed
+// by file name, and notes on what could not be translated.
+type ImportResult struct {
+	Features map[string]string
+	Notes    []string
+}
+
+// add stores a feature under a file name derived from name, numbering it if
+// another feature already uses that name.
+func (r *ImportResult) add(name, content string) {
+	file := featureFileName(name)
+	base := strings.TrimSuffix(file, ".feature")
+	for n := 2; r.Features[file] != ""; n++ {
+		file = fmt.Sprintf("%s_%d.feature", base, n)
+	}
+	r.Features[file] = content
+}
+
+func (r *ImportResult) note(format string, args ...any) {
+	if note := fmt.Sprintf(format, args...); !slices.Contains(r.Notes, note) {
+		r.Notes = append(r.Notes, note)
+	}
+}
+
+// splitBaseURL separates the scheme and host of an absolute URL from its
+// path and query.
+func splitBaseURL(raw string) (string, string) {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return "", raw
+	}
+	host, path, found := strings.Cut(rest, "/")
+	if !found {
+		if i := strings.IndexByte(host, '?'); i >= 0 {
+			host, path = host[:i], host[i:]
+			return scheme + "://" + host, "/" + path
+		}
+		return scheme + "://" + host, "/"
+	}
+	return scheme + "://" + host, "/" + path
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxGeneratorDepth bounds template generators referring to other
+// generators, catching accidental cycles.
+const maxGeneratorDepth = 10
+
+// Generator is a custom fake data tag defined in the config. Exactly one of
+// Regex, Template or Choices is set.
+type Generator struct {
+	// Regex generates strings matching the expression, e.g. "[A-Z]{3}-\d{5}".
+	Regex string
+	// Template is expanded like a data specification pattern and may use
+	// gofakeit tags as well as other custom generators.
+	Template string
+	// Choices picks one value, weighted by each choice's weight.
+	Choices []Choice
+}
+
+// Choice is a value of a choice generator. A zero weight counts as 1.
+type Choice struct {
+	Value  string
+	Weight float64
+}
+
+// ParseGenerators builds generators from their config representation. A
+// plain string is a regex; a map holds one of "regex", "template" or
+// "choices", where choices are strings or {value, weight} maps.
+func ParseGenerators(raw map[string]any) (map[string]Generator, error) {
+	generators := make(map[string]Generator, len(raw))
+
+	for name, def := range raw {
+		var gen Generator
+		switch d := def.(type) {
+		case string:
+			gen.Regex = d
+		case map[string]any:
+			regex, _ := d["regex"].(string)
+			template, _ := d["template"].(string)
+			gen.Regex, gen.Template = regex, template
+
+			if rawChoices, ok := d["choices"].([]any); ok {
+				choices, err := parseChoices(rawChoices)
+				if err != nil {
+					return nil, fmt.Errorf("generator %q: %w", name, err)
+				}
+				gen.Choices = choices
+			}
+		default:
+			return nil, fmt.Errorf("generator %q must be a regex string or a map", name)
+		}
+
+		defined := 0
+		for _, set := range []bool{gen.Regex != "", gen.Template != "", len(gen.Choices) > 0} {
+			if set {
+				defined++
+			}
+		}
+		if defined != 1 {
+			return nil, fmt.Errorf("generator %q must define exactly one of regex, template or choices", name)
+		}
+		if gen.Regex != "" {
+			if _, err := regexp.Compile(gen.Regex); err != nil {
+				return nil, fmt.Errorf("generator %q: invalid regex: %w", name, err)
+			}
+		}
+
+		generators[strings.ToLower(name)] = gen
+	}
+
+	return generators, nil
+}
+
+func parseChoices(raw []any) ([]Choice, error) {
+	choices := make([]Choice, 0, len(raw))
+	for _, item := range raw {
+		switch c := item.(type) {
+		case map[string]any:
+			value, ok := c["value"]
+			if !ok {
+				return nil, fmt.Errorf("choice %v has no value", c)
+			}
+			weight, _ := toNumber(c["weight"])
+			if weight < 0 {
+				return nil, fmt.Errorf("choice %v has a negative weight", value)
+			}
+			choices = append(choices, Choice{Value: formatValue(value), Weight: weight})
+		default:
+			choices = append(choices, Choice{Value: formatValue(c)})
+		}
+	}
+	return choices, nil
+}
+
+func (a *APITest) runGenerator(gen Generator, locale string, depth int) (string, error) {
+	switch {
+	case gen.Regex != "":
+		return a.faker.Regex(gen.Regex), nil
+	case gen.Template != "":
+		return a.expandTags(gen.Template, locale, depth+1)
+	default:
+		total := 0.0
+		for _, c := range gen.Choices {
+			total += choiceWeight(c)
+		}
+		pick := a.faker.Float64Range(0, total)
+		for _, c := range gen.Choices {
+			if pick -= choiceWeight(c); pick < 0 {
+				return c.Value, nil
+			}
+		}
+		return gen.Choices[len(gen.Choices)-1].Value, nil
+	}
+}
+
+func choiceWeight(c Choice) float64 {
+	if c.Weight == 0 {
+		return 1
+	}
+	return c.Weight
+}
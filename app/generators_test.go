@@ -0,0 +1,87 @@
+package app
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParseGenerators(t *testing.T) {
+	generators, err := ParseGenerators(map[string]any{
+		"sku": `[A-Z]{3}-\d{5}`,
+		"plan": map[string]any{
+			"choices": []any{
+				map[string]any{"value": "free", "weight": 3},
+				"pro",
+			},
+		},
+		"Tenant_Slug": map[string]any{"template": "{lettern:6}-{sku}"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if generators["sku"].Regex != `[A-Z]{3}-\d{5}` {
+		t.Errorf("Expected regex generator, got %+v", generators["sku"])
+	}
+	if len(generators["plan"].Choices) != 2 || generators["plan"].Choices[0].Weight != 3 {
+		t.Errorf("Expected weighted choices, got %+v", generators["plan"])
+	}
+	if _, ok := generators["tenant_slug"]; !ok {
+		t.Error("Expected generator names to be lowercased")
+	}
+
+	_, err = ParseGenerators(map[string]any{"both": map[string]any{"regex": "a", "template": "b"}})
+	if err == nil {
+		t.Error("Expected error for generator with two definitions, got nil")
+	}
+
+	_, err = ParseGenerators(map[string]any{"number": 5})
+	if err == nil {
+		t.Error("Expected error for invalid generator, got nil")
+	}
+
+	for _, def := range []any{`[A-Z`, map[string]any{"regex": `(\d{5}`}} {
+		_, err = ParseGenerators(map[string]any{"sku": def})
+		if err == nil || !strings.Contains(err.Error(), `generator "sku": invalid regex`) {
+			t.Errorf("Expected an invalid regex error for %v, got %v", def, err)
+		}
+	}
+}
+
+func TestIGenerateFakeDataWithCustomGenerators(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	generators, err := ParseGenerators(map[string]any{
+		"sku":   `[A-Z]{3}-\d{5}`,
+		"plan":  map[string]any{"choices": []any{"free", map[string]any{"value": "pro", "weight": 0.0001}}},
+		"slug":  map[string]any{"template": "{lettern:4}-{sku}"},
+		"cycle": map[string]any{"template": "{cycle}"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	apiTest.generators = generators
+
+	err = apiTest.iGenerateFakeData("custom_sku={sku}, custom_plan={plan}, custom_slug={slug}, custom_email={email}")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sku := apiTest.store["custom_sku"].(string); !regexp.MustCompile(`^[A-Z]{3}-\d{5}$`).MatchString(sku) {
+		t.Errorf("Expected sku matching regex, got %q", sku)
+	}
+	if plan := apiTest.store["custom_plan"]; plan != "free" && plan != "pro" {
+		t.Errorf("Expected plan from choices, got %v", plan)
+	}
+	if slug := apiTest.store["custom_slug"].(string); !regexp.MustCompile(`^[A-Za-z]{4}-[A-Z]{3}-\d{5}$`).MatchString(slug) {
+		t.Errorf("Expected slug composed from template, got %q", slug)
+	}
+	if email := apiTest.store["custom_email"].(string); !strings.Contains(email, "@") {
+		t.Errorf("Expected built-in tags to keep working, got %q", email)
+	}
+
+	err = apiTest.iGenerateFakeData("custom_cycle={cycle}")
+	if err == nil {
+		t.Error("Expected error for self-referencing generator, got nil")
+	}
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/tidwall/gjson"
+)
+
+// graphQLRequest is the envelope GraphQL servers accept over HTTP.
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// iSetTheGraphQLVariables sets the variables of the next GraphQL query from
+// a table of names and values. Values that are valid JSON keep their type,
+// so quote a value to send it as a string.
+func (a *APITest) iSetTheGraphQLVariables(table *godog.Table) error {
+	variables := map[string]any{}
+	for i, row := range table.Rows {
+		if len(row.Cells) != 2 {
+			return fmt.Errorf("GraphQL variables row %d must have a name and a value", i+1)
+		}
+		name, value := row.Cells[0].Value, row.Cells[1].Value
+		if i == 0 && name == "name" && value == "value" {
+			continue
+		}
+
+		value, err := a.replaceVars(value)
+		if err != nil {
+			return err
+		}
+		var typed any
+		if err := json.Unmarshal([]byte(value), &typed); err != nil {
+			typed = value
+		}
+		variables[name] = typed
+	}
+	a.graphQLVariables = variables
+
+	if a.debug {
+		fmt.Printf("Set GraphQL variables: %v\n", variables)
+	}
+
+	return nil
+}
+
+func (a *APITest) iSetTheGraphQLVariablesTo(document string) error {
+	rendered, err := a.renderJSON(document)
+	if err != nil {
+		return err
+	}
+
+	var variables map[string]any
+	if err := json.Unmarshal([]byte(rendered), &variables); err != nil {
+		return fmt.Errorf("GraphQL variables must be a JSON object: %w", err)
+	}
+	a.graphQLVariables = variables
+
+	if a.debug {
+		fmt.Printf("Set GraphQL variables: %s\n", rendered)
+	}
+
+	return nil
+}
+
+// iSendAGraphQLQueryTo posts a query with the variables set beforehand,
+// which apply to this query only. Errors in the response fail the next
+// GraphQL query, or the scenario when it ends, unless a GraphQL error step
+// expects them.
+func (a *APITest) iSendAGraphQLQueryTo(endpoint, query string) error {
+	if err := a.checkGraphQLErrors(); err != nil {
+		return err
+	}
+	a.lastRequest = nil
+
+	endpoint, err := a.replaceVars(endpoint)
+	if err != nil {
+		return err
+	}
+	query, err = a.replaceVars(query)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(graphQLRequest{Query: query, Variables: a.graphQLVariables})
+	if err != nil {
+		return fmt.Errorf("failed to encode GraphQL request: %w", err)
+	}
+	a.graphQLVariables = nil
+
+	if err := a.doRequest("POST", endpoint, string(payload)); err != nil {
+		return err
+	}
+
+	if errors := gjson.Get(a.responseBody, "errors"); errors.IsArray() && len(errors.Array()) > 0 {
+		a.graphQLErrors = errors.Raw
+	}
+
+	return nil
+}
+
+// checkGraphQLErrors fails when the last GraphQL response had errors no step
+// expected, clearing them so they are reported once.
+func (a *APITest) checkGraphQLErrors() error {
+	if a.graphQLErrors == "" {
+		return nil
+	}
+	errors := a.graphQLErrors
+	a.graphQLErrors = ""
+	return fmt.Errorf("unexpected GraphQL errors: %s\nexpect them with: Then the GraphQL response should have errors", errors)
+}
+
+func (a *APITest) theGraphQLResponseShouldHaveNoErrors() error {
+	a.graphQLErrors = ""
+
+	errors := gjson.Get(a.responseBody, "errors")
+	if errors.IsArray() && len(errors.Array()) > 0 {
+		var messages []string
+		for _, e := range errors.Array() {
+			messages = append(messages, e.Get("message").String())
+		}
+		return fmt.Errorf("expected no GraphQL errors but got: %s", strings.Join(messages, "; "))
+	}
+
+	if a.debug {
+		fmt.Printf("GraphQL response has no errors\n")
+	}
+
+	return nil
+}
+
+func (a *APITest) theGraphQLResponseShouldHaveErrors() error {
+	errors := gjson.Get(a.responseBody, "errors")
+	if !errors.IsArray() || len(errors.Array()) == 0 {
+		return fmt.Errorf("expected GraphQL errors in response %s", a.responseBody)
+	}
+	a.graphQLErrors = ""
+
+	if a.debug {
+		fmt.Printf("GraphQL response has errors: %s\n", errors.Raw)
+	}
+
+	return nil
+}
+
+// theGraphQLErrorShouldBe passes when any error in the response has the
+// property with the expected value.
+func (a *APITest) theGraphQLErrorShouldBe(property, expectedValue string) error {
+	expected, err := a.replaceVars(expectedValue)
+	if err != nil {
+		return err
+	}
+	if len(expected) >= 2 && strings.HasPrefix(expected, "\"") && strings.HasSuffix(expected, "\"") {
+		expected = expected[1 : len(expected)-1]
+	}
+
+	errors := gjson.Get(a.responseBody, "errors")
+	if !errors.IsArray() || len(errors.Array()) == 0 {
+		return fmt.Errorf("expected a GraphQL error with %s %s but the response has no errors: %s", property, expected, a.responseBody)
+	}
+
+	var found []string
+	for _, e := range errors.Array() {
+		value := e.Get(property)
+		if !value.Exists() {
+			continue
+		}
+		if value.String() == expected || value.Raw == expected {
+			a.graphQLErrors = ""
+			if a.debug {
+				fmt.Printf("GraphQL error %s: %s\n", property, value.String())
+			}
+			return nil
+		}
+		found = append(found, value.String())
+	}
+
+	if len(found) == 0 {
+		return fmt.Errorf("no GraphQL error has %s: %s", property, errors.Raw)
+	}
+	return fmt.Errorf("expected a GraphQL error with %s %s but got %s", property, expected, strings.Join(found, ", "))
+}
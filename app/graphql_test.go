@@ -0,0 +1,103 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cucumber/godog"
+	messages "github.com/cucumber/messages/go/v21"
+)
+
+func TestGraphQL(t *testing.T) {
+	var received graphQLRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		if received.Variables["id"] == "forbidden" {
+			w.Write([]byte(`{"data": null, "errors": [{"message": "not allowed", "extensions": {"code": "FORBIDDEN"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"user": {"id": "u1"}}}`))
+	}))
+	defer server.Close()
+
+	apiTest := NewAPITest(server.URL)
+	apiTest.store["user_id"] = "u1"
+
+	table := &godog.Table{Rows: []*messages.PickleTableRow{
+		{Cells: []*messages.PickleTableCell{{Value: "id"}, {Value: "${user_id}"}}},
+		{Cells: []*messages.PickleTableCell{{Value: "limit"}, {Value: "10"}}},
+	}}
+	if err := apiTest.iSetTheGraphQLVariables(table); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iSendAGraphQLQueryTo("/graphql", "query User($id: ID!) { user(id: $id) { id } }"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received.Variables["id"] != "u1" || received.Variables["limit"] != float64(10) {
+		t.Errorf("Expected typed variables, got %v", received.Variables)
+	}
+	if !strings.HasPrefix(received.Query, "query User") {
+		t.Errorf("Expected the query in the envelope, got %q", received.Query)
+	}
+	if err := apiTest.theGraphQLResponseShouldHaveNoErrors(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theResponsePropertyShouldBe("data.user.id", `"u1"`); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if err := apiTest.iSetTheGraphQLVariablesTo(`{"id": "forbidden"}`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iSendAGraphQLQueryTo("/graphql", "{ user { id } }"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theGraphQLErrorShouldBe("extensions.code", `"NOT_FOUND"`); err == nil {
+		t.Errorf("Expected an error for a different code")
+	}
+	if err := apiTest.theGraphQLErrorShouldBe("extensions.code", `"FORBIDDEN"`); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := apiTest.checkGraphQLErrors(); err != nil {
+		t.Errorf("Expected expected errors not to fail, got %v", err)
+	}
+
+	if err := apiTest.iSetTheGraphQLVariablesTo(`{"id": "forbidden"}`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iSendAGraphQLQueryTo("/graphql", "{ user { id } }"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	err := apiTest.checkGraphQLErrors()
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("Expected unexpected errors to fail, got %v", err)
+	}
+}
+
+func TestGraphQLErrorsDoNotFailHTTPRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/graphql" {
+			w.Write([]byte(`{"data": null, "errors": [{"message": "not allowed"}]}`))
+			return
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	apiTest := NewAPITest(server.URL)
+	if err := apiTest.iSendAGraphQLQueryTo("/graphql", "{ user { id } }"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iSendRequestTo("GET", "/health"); err != nil {
+		t.Errorf("Expected a plain request not to fail for GraphQL errors, got %v", err)
+	}
+
+	err := apiTest.iSendAGraphQLQueryTo("/graphql", "{ user { id } }")
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("Expected the next GraphQL query to report unexpected errors, got %v", err)
+	}
+}
@@ -0,0 +1,498 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// reflectionMethods are the server reflection endpoints tried in order. The
+// v1alpha messages are identical on the wire, so both use the v1 types.
+var reflectionMethods = []string{
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+}
+
+// grpcResult holds the outcome of the last gRPC call.
+type grpcResult struct {
+	status  *status.Status
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (a *APITest) iUseTheGRPCServer(address string) error {
+	address, err := a.replaceVars(address)
+	if err != nil {
+		return err
+	}
+	a.scenarioGRPCAddress = address
+
+	if a.debug {
+		fmt.Printf("Using gRPC server %s\n", address)
+	}
+
+	return nil
+}
+
+func (a *APITest) iUseTheProtoFile(path string) error {
+	path, err := a.replaceVars(path)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(a.scenarioProtoFiles, path) {
+		a.scenarioProtoFiles = append(a.scenarioProtoFiles, path)
+	}
+
+	if a.debug {
+		fmt.Printf("Using proto file %s\n", path)
+	}
+
+	return nil
+}
+
+func (a *APITest) iCallGRPC(method string) error {
+	return a.iCallGRPCWith(method, "{}")
+}
+
+// iCallGRPCWith calls a unary method with a JSON request. The response is
+// stored as JSON so the response property steps work on it, and a failed
+// call stores its status as {"code": ..., "message": ...}.
+func (a *APITest) iCallGRPCWith(method, payload string) error {
+	a.lastRequest = nil
+	a.response = nil
+	a.resetTiming()
+
+	target, useTLS, err := a.grpcTarget()
+	if err != nil {
+		return err
+	}
+	rendered, err := a.renderJSON(payload)
+	if err != nil {
+		return err
+	}
+
+	conn, err := a.grpcConn(target, useTLS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if a.client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.client.Timeout)
+		defer cancel()
+	}
+
+	descriptor, err := a.findMethod(ctx, conn, method)
+	if err != nil {
+		return err
+	}
+	if descriptor.IsStreamingClient() || descriptor.IsStreamingServer() {
+		return fmt.Errorf("gRPC method %s is streaming, only unary methods are supported", method)
+	}
+
+	request := dynamicpb.NewMessage(descriptor.Input())
+	if err := protojson.Unmarshal([]byte(rendered), request); err != nil {
+		return fmt.Errorf("invalid request for %s: %w", descriptor.Input().FullName(), err)
+	}
+
+	md := metadata.MD{}
+	for k, v := range a.headers {
+		if strings.EqualFold(k, "Content-Type") {
+			continue
+		}
+		value, err := a.replaceVars(v)
+		if err != nil {
+			return err
+		}
+		md.Set(k, value)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	if a.debug {
+		fmt.Printf("Calling gRPC %s on %s with: %s\n", method, target, rendered)
+	}
+
+	result := &grpcResult{}
+	response := dynamicpb.NewMessage(descriptor.Output())
+	fullMethod := "/" + string(descriptor.Parent().FullName()) + "/" + string(descriptor.Name())
+	callErr := conn.Invoke(ctx, fullMethod, request, response, grpc.Header(&result.header), grpc.Trailer(&result.trailer))
+	result.status = status.Convert(callErr)
+	a.grpc = result
+
+	if callErr != nil {
+		body, _ := json.Marshal(map[string]string{"code": grpcCodeName(result.status.Code()), "message": result.status.Message()})
+		a.responseBody = string(body)
+	} else {
+		body, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(response)
+		if err != nil {
+			return fmt.Errorf("failed to convert gRPC response to JSON: %w", err)
+		}
+		a.responseBody = string(body)
+	}
+
+	if a.debug {
+		fmt.Printf("gRPC status: %s\n", grpcCodeName(result.status.Code()))
+		fmt.Printf("Response body: %s\n", a.responseBody)
+	}
+
+	return nil
+}
+
+// grpcConn returns the connection to target, reusing it across calls and
+// scenarios. Connections are closed when the suite ends.
+func (a *APITest) grpcConn(target string, useTLS bool) (*grpc.ClientConn, error) {
+	key := target
+	if useTLS {
+		key = "tls:" + target
+	}
+	if conn, ok := a.grpcConns[key]; ok {
+		return conn, nil
+	}
+
+	var opts []grpc.DialOption
+	if useTLS {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gRPC server %s: %w", target, err)
+	}
+
+	if a.grpcConns == nil {
+		a.grpcConns = map[string]*grpc.ClientConn{}
+	}
+	a.grpcConns[key] = conn
+	return conn, nil
+}
+
+// closeGRPCConns closes the connections opened during the suite.
+func (a *APITest) closeGRPCConns() {
+	for _, conn := range a.grpcConns {
+		conn.Close()
+	}
+	a.grpcConns = nil
+}
+
+// grpcTarget returns the gRPC server address, defaulting to the host of the
+// base URL. An https:// address uses TLS.
+func (a *APITest) grpcTarget() (string, bool, error) {
+	address := a.scenarioGRPCAddress
+	if address == "" {
+		address = a.grpcAddress
+	}
+	if address == "" {
+		address = a.baseURL
+	}
+	if address == "" {
+		return "", false, fmt.Errorf(`no gRPC server configured, add: Given I use the gRPC server "localhost:50051"`)
+	}
+
+	if !strings.Contains(address, "://") {
+		return address, false, nil
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid gRPC server %q: %w", address, err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host += ":443"
+		} else {
+			host += ":80"
+		}
+	}
+	return host, u.Scheme == "https", nil
+}
+
+// findMethod resolves a method such as "users.v1.UserService/GetUser" from
+// the configured proto files, or from server reflection when there are none.
+func (a *APITest) findMethod(ctx context.Context, conn *grpc.ClientConn, method string) (protoreflect.MethodDescriptor, error) {
+	service, name, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid gRPC method %q, expected package.Service/Method", method)
+	}
+
+	var descriptor protoreflect.Descriptor
+	var err error
+	if files := a.grpcProtoFiles(); len(files) > 0 {
+		descriptor, err = a.findInProtoFiles(ctx, files, service)
+	} else {
+		descriptor, err = findByReflection(ctx, conn, service)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sd, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a gRPC service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("gRPC service %s has no method %s", service, name)
+	}
+	return md, nil
+}
+
+// grpcProtoFiles returns the configured proto files followed by those added
+// in the scenario.
+func (a *APITest) grpcProtoFiles() []string {
+	files := slices.Clone(a.protoFiles)
+	for _, file := range a.scenarioProtoFiles {
+		if !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// findInProtoFiles compiles files once per set of files and looks the
+// service up in them.
+func (a *APITest) findInProtoFiles(ctx context.Context, files []string, service string) (protoreflect.Descriptor, error) {
+	key := strings.Join(files, "\x00")
+	resolver, ok := a.protoDescriptors[key]
+	if !ok {
+		compiler := protocompile.Compiler{
+			Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: a.protoImportPaths}),
+		}
+		compiled, err := compiler.Compile(ctx, files...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile proto files: %w", err)
+		}
+		resolver = compiled.AsResolver()
+
+		if a.protoDescriptors == nil {
+			a.protoDescriptors = map[string]linker.Resolver{}
+		}
+		a.protoDescriptors[key] = resolver
+	}
+
+	descriptor, err := resolver.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("gRPC service %s not found in %s", service, strings.Join(files, ", "))
+	}
+	return descriptor, nil
+}
+
+// findByReflection asks the server for the file defining a symbol and the
+// files it depends on.
+func findByReflection(ctx context.Context, conn *grpc.ClientConn, symbol string) (protoreflect.Descriptor, error) {
+	var errs []error
+	for _, method := range reflectionMethods {
+		descriptor, err := reflectSymbol(ctx, conn, method, symbol)
+		if err == nil {
+			return descriptor, nil
+		}
+		if status.Code(err) != codes.Unimplemented {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("gRPC server reflection is not available, configure proto_files instead: %w", errors.Join(errs...))
+}
+
+func reflectSymbol(ctx context.Context, conn *grpc.ClientConn, method, symbol string) (protoreflect.Descriptor, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	desc := &grpc.StreamDesc{StreamName: "ServerReflectionInfo", ServerStreams: true, ClientStreams: true}
+	stream, err := conn.NewStream(ctx, desc, method)
+	if err != nil {
+		return nil, err
+	}
+
+	protos := map[string]*descriptorpb.FileDescriptorProto{}
+	request := func(req *reflectionpb.ServerReflectionRequest) error {
+		if err := stream.SendMsg(req); err != nil {
+			return err
+		}
+		resp := &reflectionpb.ServerReflectionResponse{}
+		if err := stream.RecvMsg(resp); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("gRPC server closed the reflection stream")
+			}
+			return err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return status.Error(codes.Code(e.ErrorCode), e.ErrorMessage)
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return fmt.Errorf("invalid file descriptor from reflection: %w", err)
+			}
+			protos[fd.GetName()] = fd
+		}
+		return nil
+	}
+
+	err = request(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("gRPC service %s not found by server reflection", symbol)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Servers usually send dependencies along, but fetch any that are missing.
+	for missing := missingDependencies(protos); len(missing) > 0; missing = missingDependencies(protos) {
+		for _, name := range missing {
+			err := request(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch %s by reflection: %w", name, err)
+			}
+			if _, ok := protos[name]; !ok {
+				return nil, fmt.Errorf("gRPC server reflection did not return %s", name)
+			}
+		}
+	}
+	stream.CloseSend()
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, name := range slices.Sorted(maps.Keys(protos)) {
+		set.File = append(set.File, protos[name])
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptors from reflection: %w", err)
+	}
+	return files.FindDescriptorByName(protoreflect.FullName(symbol))
+}
+
+func missingDependencies(protos map[string]*descriptorpb.FileDescriptorProto) []string {
+	var missing []string
+	for _, fd := range protos {
+		for _, dep := range fd.GetDependency() {
+			if _, ok := protos[dep]; !ok && !slices.Contains(missing, dep) {
+				missing = append(missing, dep)
+			}
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+func (a *APITest) theGRPCStatusShouldBe(expected string) error {
+	if a.grpc == nil {
+		return fmt.Errorf("no gRPC call has been made in this scenario")
+	}
+
+	expected, err := a.replaceVars(expected)
+	if err != nil {
+		return err
+	}
+	code, err := parseGRPCCode(expected)
+	if err != nil {
+		return err
+	}
+
+	actual := a.grpc.status.Code()
+	if actual != code {
+		return fmt.Errorf("expected gRPC status %s but got %s: %s", grpcCodeName(code), grpcCodeName(actual), a.grpc.status.Message())
+	}
+
+	if a.debug {
+		fmt.Printf("gRPC status %s matches expected %s\n", grpcCodeName(actual), grpcCodeName(code))
+	}
+
+	return nil
+}
+
+// parseGRPCCode accepts a code by number or by name, such as NOT_FOUND.
+func parseGRPCCode(value string) (codes.Code, error) {
+	if n, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return codes.Code(n), nil
+	}
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(value)))); err != nil {
+		return 0, fmt.Errorf("unknown gRPC status %q", value)
+	}
+	return code, nil
+}
+
+// grpcCodeName returns a status code's canonical name, such as NOT_FOUND.
+func grpcCodeName(code codes.Code) string {
+	if code == codes.Canceled {
+		return "CANCELLED"
+	}
+	return strings.ToUpper(camelBoundary.ReplaceAllString(code.String(), "${1}_${2}"))
+}
+
+func (a *APITest) theGRPCStatusMessageShouldBe(expected string) error {
+	if a.grpc == nil {
+		return fmt.Errorf("no gRPC call has been made in this scenario")
+	}
+
+	expected, err := a.replaceVars(expected)
+	if err != nil {
+		return err
+	}
+	if actual := a.grpc.status.Message(); actual != expected {
+		return fmt.Errorf("expected gRPC status message %q but got %q", expected, actual)
+	}
+
+	return nil
+}
+
+// theGRPCMetadataShouldBe checks a response header or trailer, passing when
+// any of its values matches.
+func (a *APITest) theGRPCMetadataShouldBe(kind, key, expected string) error {
+	if a.grpc == nil {
+		return fmt.Errorf("no gRPC call has been made in this scenario")
+	}
+
+	expected, err := a.replaceVars(expected)
+	if err != nil {
+		return err
+	}
+
+	md := a.grpc.header
+	if kind == "trailer" {
+		md = a.grpc.trailer
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return fmt.Errorf("gRPC %s %s not found", kind, key)
+	}
+	if !slices.Contains(values, expected) {
+		return fmt.Errorf("expected gRPC %s %s to be %q but got %q", kind, key, expected, strings.Join(values, ", "))
+	}
+
+	if a.debug {
+		fmt.Printf("gRPC %s %s matches expected %s\n", kind, key, expected)
+	}
+
+	return nil
+}
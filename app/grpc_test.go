@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const usersProto = `syntax = "proto3";
+package users.v1;
+
+import "google/protobuf/timestamp.proto";
+
+message GetUserRequest { string id = 1; }
+message User {
+  string id = 1;
+  string name = 2;
+  bool admin = 3;
+  google.protobuf.Timestamp created_at = 4;
+}
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+}
+`
+
+// startUserService serves users.v1.UserService with server reflection,
+// without generated code.
+func startUserService(t *testing.T, protoPath string) string {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{filepath.Dir(protoPath)}}),
+	}
+	files, err := compiler.Compile(context.Background(), filepath.Base(protoPath))
+	if err != nil {
+		t.Fatalf("Failed to compile proto: %v", err)
+	}
+	service := files[0].Services().ByName("UserService")
+	method := service.Methods().ByName("GetUser")
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: string(service.FullName()),
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "GetUser",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := dynamicpb.NewMessage(method.Input())
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				md, _ := metadata.FromIncomingContext(ctx)
+				id := req.Get(method.Input().Fields().ByName("id")).String()
+				if id != "u1" {
+					return nil, status.Errorf(codes.NotFound, "user %s not found", id)
+				}
+				grpc.SetTrailer(ctx, metadata.Pairs("x-tenant", strings.Join(md.Get("x-tenant"), ",")))
+
+				user := dynamicpb.NewMessage(method.Output())
+				fields := method.Output().Fields()
+				user.Set(fields.ByName("id"), protoreflect.ValueOfString(id))
+				user.Set(fields.ByName("name"), protoreflect.ValueOfString("John"))
+				return user, nil
+			},
+		}},
+	}, struct{}{})
+	reflectionpb.RegisterServerReflectionServer(server, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           server,
+		DescriptorResolver: files.AsResolver(),
+	}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+func TestGRPC(t *testing.T) {
+	dir := t.TempDir()
+	protoPath := filepath.Join(dir, "users.proto")
+	os.WriteFile(protoPath, []byte(usersProto), 0o644)
+	address := startUserService(t, protoPath)
+
+	apiTest := NewAPITest("")
+	t.Cleanup(apiTest.closeGRPCConns)
+	apiTest.headers = map[string]string{"Content-Type": "application/json", "X-Tenant": "acme"}
+	apiTest.store["user_id"] = "u1"
+	if err := apiTest.iUseTheGRPCServer(address); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Resolved by server reflection.
+	if err := apiTest.iCallGRPCWith("users.v1.UserService/GetUser", `{"id": "${user_id}"}`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theGRPCStatusShouldBe("OK"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theResponsePropertyShouldBe("name", `"John"`); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theResponsePropertyShouldBe("admin", "false"); err != nil {
+		t.Errorf("Expected unpopulated fields in the response, got %v", err)
+	}
+	if err := apiTest.theGRPCMetadataShouldBe("trailer", "x-tenant", "acme"); err != nil {
+		t.Errorf("Expected headers to be sent as metadata, got %v", err)
+	}
+
+	// Resolved from the proto file.
+	apiTest.protoImportPaths = []string{dir}
+	if err := apiTest.iUseTheProtoFile("users.proto"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iCallGRPCWith("users.v1.UserService/GetUser", `{"id": "u2"}`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theGRPCStatusShouldBe("NOT_FOUND"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theGRPCStatusShouldBe("5"); err != nil {
+		t.Errorf("Expected codes by number, got %v", err)
+	}
+	if err := apiTest.theGRPCStatusMessageShouldBe("user u2 not found"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theResponsePropertyShouldBe("code", `"NOT_FOUND"`); err != nil {
+		t.Errorf("Expected the status in the response, got %v", err)
+	}
+
+	err := apiTest.iCallGRPC("users.v1.UserService/DeleteUser")
+	if err == nil || !strings.Contains(err.Error(), "has no method DeleteUser") {
+		t.Errorf("Expected an unknown method error, got %v", err)
+	}
+
+	if len(apiTest.grpcConns) != 1 {
+		t.Errorf("Expected the connection to be reused, got %d connections", len(apiTest.grpcConns))
+	}
+	if len(apiTest.protoDescriptors) != 1 {
+		t.Errorf("Expected the proto files to be compiled once, got %d", len(apiTest.protoDescriptors))
+	}
+	if apiTest.grpcAddress != "" || len(apiTest.protoFiles) != 0 {
+		t.Errorf("Expected steps not to change the config, got %q and %v", apiTest.grpcAddress, apiTest.protoFiles)
+	}
+}
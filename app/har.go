@@ -0,0 +1,145 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// harSkippedHeaders are request headers set by browsers or the HTTP client
+// rather than by the API's callers.
+var harSkippedHeaders = map[string]bool{
+	"accept-encoding":           true,
+	"accept-language":           true,
+	"cache-control":             true,
+	"connection":                true,
+	"content-length":            true,
+	"cookie":                    true,
+	"host":                      true,
+	"origin":                    true,
+	"pragma":                    true,
+	"referer":                   true,
+	"user-agent":                true,
+	"priority":                  true,
+	"dnt":                       true,
+	"upgrade-insecure-requests": true,
+	"if-none-match":             true,
+	"if-modified-since":         true,
+}
+
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method   string      `json:"method"`
+		URL      string      `json:"url"`
+		Headers  []harHeader `json:"headers"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ImportHAR converts the API calls recorded in a HAR file into a feature
+// with one scenario per request, asserting the recorded status. Requests
+// for pages and static assets, recognised by a non-JSON response to a GET,
+// are skipped.
+func ImportHAR(path string) (*ImportResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HAR file: %w", err)
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("invalid HAR file %s: %w", path, err)
+	}
+
+	result := &ImportResult{Features: map[string]string{}}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Feature: %s\n  Imported from %s.\n", name, filepath.Base(path))
+
+	baseURLs := map[string]bool{}
+	skipped, scenarios := 0, 0
+	for _, entry := range har.Log.Entries {
+		req := entry.Request
+		if req.Method == "GET" && !strings.Contains(entry.Response.Content.MimeType, "json") {
+			skipped++
+			continue
+		}
+
+		base, endpoint := splitBaseURL(req.URL)
+		if base != "" {
+			baseURLs[base] = true
+		}
+		title := req.Method + " " + strings.SplitN(endpoint, "?", 2)[0]
+		if strings.Contains(endpoint, `"`) {
+			result.note("%s: URL contains a double quote and was not imported", title)
+			continue
+		}
+
+		sb.WriteString("\n")
+		w := newScenarioWriter(&sb, title)
+
+		for _, header := range req.Headers {
+			key := strings.ToLower(header.Name)
+			switch {
+			case harSkippedHeaders[key] || strings.HasPrefix(key, ":") || strings.HasPrefix(key, "sec-"):
+				if key == "cookie" {
+					result.note("%s: cookies not translated", title)
+				}
+				continue
+			case strings.Contains(header.Value, `"`):
+				result.note("%s: header %s contains a double quote and was not imported", title, header.Name)
+				continue
+			}
+			w.step("Given", `I set header "%s" to "%s"`, header.Name, header.Value)
+		}
+
+		if req.PostData != nil && req.PostData.Text != "" {
+			w.step("When", `I send a "%s" request to "%s" with payload:`, req.Method, endpoint)
+			w.docString(req.PostData.Text)
+		} else {
+			w.step("When", `I send a "%s" request to "%s"`, req.Method, endpoint)
+		}
+		if entry.Response.Status > 0 {
+			w.step("Then", "the response status should be %d", entry.Response.Status)
+		}
+		scenarios++
+	}
+
+	if skipped > 0 {
+		result.note("skipped %d GET requests without a JSON response (pages and static assets)", skipped)
+	}
+	for _, base := range slices.Sorted(maps.Keys(baseURLs)) {
+		result.note("requests use %s as the base URL; set it as base_url in rbdd.yaml or API_BASE_URL", base)
+	}
+
+	if scenarios > 0 {
+		result.add(name, sb.String())
+	}
+
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportHAR(t *testing.T) {
+	har := `{"log": {"entries": [
+ {"request": {"method": "GET", "url": "https://app.test/index.html", "headers": []}, "response": {"status": 200, "content": {"mimeType": "text/html"}}},
+ {"request": {"method": "POST", "url": "https://app.test/api/login?next=%2F", "headers": [{"name": ":authority", "value": "app.test"}, {"name": "Content-Type", "value": "application/json"}, {"name": "Cookie", "value": "a=b"}], "postData": {"mimeType": "application/json", "text": "{\"user\":\"jo\"}"}}, "response": {"status": 201, "content": {"mimeType": "application/json"}}},
+ {"request": {"method": "GET", "url": "https://app.test/api/me", "headers": [{"name": "Authorization", "value": "Bearer xyz"}]}, "response": {"status": 200, "content": {"mimeType": "application/json; charset=utf-8"}}}
+]}}`
+	path := filepath.Join(t.TempDir(), "session.har")
+	if err := os.WriteFile(path, []byte(har), 0o644); err != nil {
+		t.Fatalf("Failed to write HAR: %v", err)
+	}
+
+	result, err := ImportHAR(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := `Feature: session
+  Imported from session.har.
+
+  Scenario: POST /api/login
+    Given I set header "Content-Type" to "application/json"
+    When I send a "POST" request to "/api/login?next=%2F" with payload:
+      """
+      {"user":"jo"}
+      """
+    Then the response status should be 201
+
+  Scenario: GET /api/me
+    Given I set header "Authorization" to "Bearer xyz"
+    When I send a "GET" request to "/api/me"
+    Then the response status should be 200
+`
+	if got := result.Features["session.feature"]; got != expected {
+		t.Errorf("Expected feature:\n%s\ngot:\n%s", expected, got)
+	}
+
+	notes := strings.Join(result.Notes, "\n")
+	for _, note := range []string{"cookies not translated", "skipped 1 GET requests", "https://app.test as the base URL"} {
+		if !strings.Contains(notes, note) {
+			t.Errorf("Expected notes to contain %q, got:\n%s", note, notes)
+		}
+	}
+}
@@ -6,16 +6,42 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tidwall/gjson"
 )
 
 func (a *APITest) sendRequest(method, endpoint, payload string) error {
-	endpoint = a.replaceVars(endpoint)
-	payload = a.replaceVars(payload)
+	a.lastRequest = nil
+
+	endpoint, err := a.replaceVars(endpoint)
+	if err != nil {
+		return err
+	}
+	switch {
+	case isJSONPayload(payload):
+		payload, err = a.renderJSON(payload)
+	case isXMLPayload(payload):
+		payload, err = a.renderXML(payload)
+	default:
+		payload, err = a.replaceVars(payload)
+	}
+	if err != nil {
+		return err
+	}
+
+	return a.doRequest(method, endpoint, payload)
+}
+
+// doRequest sends a request whose endpoint and payload have already been
+// rendered, storing the response.
+func (a *APITest) doRequest(method, endpoint, payload string) error {
+	// A failed request has no timing, rather than the previous one.
+	a.resetTiming()
 
 	if a.debug {
 		fmt.Printf("Sending %s request to %s with payload: %s", method, a.baseURL+endpoint, payload)
@@ -35,14 +61,37 @@ func (a *APITest) sendRequest(method, endpoint, payload string) error {
 	}
 
 	for k, v := range a.headers {
-		req.Header.Set(k, a.replaceVars(v))
+		value, err := a.replaceVars(v)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(k, value)
+	}
+	// XML payloads are not sent as the default JSON content type.
+	if isXMLPayload(payload) && req.Header.Get("Content-Type") == "application/json" {
+		req.Header.Set("Content-Type", "application/xml")
 	}
 
+	a.lastRequest = &lastRequest{method: method, url: req.URL.String(), headers: req.Header.Clone(), body: payload}
+	if a.debug {
+		fmt.Printf("Request as curl: %s\n", a.curlCommand())
+	}
+
+	trace := &requestTrace{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+	trace.start = time.Now()
 	a.response, err = a.client.Do(req)
 	if err != nil {
 		return err
 	}
 
+	// An event stream never ends, so reading it whole would hang.
+	if isEventStream(a.response) {
+		a.response.Body.Close()
+		a.responseBody = ""
+		return fmt.Errorf(`%s %s returned an event stream, use: When I subscribe to "%s"`, method, endpoint, endpoint)
+	}
+
 	bodyBytes, err := io.ReadAll(a.response.Body)
 	if err != nil {
 		return err
@@ -50,6 +99,10 @@ func (a *APITest) sendRequest(method, endpoint, payload string) error {
 	a.responseBody = string(bodyBytes)
 	a.response.Body.Close()
 
+	timing := trace.timing(time.Now())
+	timing.Scenario, timing.Method, timing.URL = a.scenario, method, endpoint
+	a.recordTiming(timing)
+
 	if a.debug {
 		fmt.Printf("Response status: %d", a.response.StatusCode)
 		fmt.Printf("Response body: %s", a.responseBody)
@@ -80,7 +133,10 @@ func (a *APITest) theResponsePropertyShouldNotBeEmpty(property string) error {
 }
 
 func (a *APITest) theResponseShouldMatchJSON(expected string) error {
-	templated := a.replaceVars(expected)
+	templated, err := a.replaceVars(expected)
+	if err != nil {
+		return err
+	}
 
 	var expectedObj any
 	var actualObj any
@@ -117,7 +173,10 @@ func (a *APITest) theResponseShouldMatchJSON(expected string) error {
 }
 
 func (a *APITest) theResponseShouldContainJSON(expected string) error {
-	templated := a.replaceVars(expected)
+	templated, err := a.replaceVars(expected)
+	if err != nil {
+		return err
+	}
 
 	var expectedMap map[string]any
 	var actualMap map[string]any
@@ -153,6 +212,9 @@ func (a *APITest) theResponseShouldContainJSON(expected string) error {
 }
 
 func (a *APITest) theResponseStatusShouldBe(status int) error {
+	if a.response == nil {
+		return fmt.Errorf("no HTTP response in this scenario")
+	}
 	if a.response.StatusCode != status {
 		return fmt.Errorf("expected status %d but got %d with body %s", status, a.response.StatusCode, a.responseBody)
 	}
@@ -166,7 +228,10 @@ func (a *APITest) theResponseStatusShouldBe(status int) error {
 
 func (a *APITest) theResponsePropertyShouldBe(property, expectedValue string) error {
 	value := gjson.Get(a.responseBody, property)
-	expected := a.replaceVars(expectedValue)
+	expected, err := a.replaceVars(expectedValue)
+	if err != nil {
+		return err
+	}
 
 	if property == "empty" || property == "not.exists" {
 		fmt.Printf("Type is %s", value.Type)
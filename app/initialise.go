@@ -1,47 +1,123 @@
 package app
 
 import (
+	"context"
 	"os"
 
 	"github.com/cucumber/godog"
 )
 
+// Config holds the run options that affect how steps behave.
+type Config struct {
+	// BaseURL is prepended to every request endpoint. API_BASE_URL is used
+	// when it is empty.
+	BaseURL string
+
+	// Strict fails any step containing a ${...} placeholder that cannot be
+	// resolved instead of sending it through verbatim.
+	Strict bool
+
+	// Variables seed the store before the first scenario runs.
+	Variables map[string]any
+
+	// Seed makes generated fake data reproducible. Each scenario derives its
+	// own seed from it; zero keeps fake data random.
+	Seed uint64
+
+	// Locale localizes names, addresses and phone numbers in fake data.
+	Locale string
+
+	// Generators are custom fake data tags usable alongside gofakeit's.
+	Generators map[string]Generator
+
+	// MaskSecrets hides header and JSON property values that look like
+	// credentials in the curl commands printed for requests.
+	MaskSecrets bool
+
+	// WebhookSecret verifies the HMAC-SHA256 signature of received webhooks
+	// when set. The signature is read from WebhookSignatureHeader, which
+	// defaults to X-Signature.
+	WebhookSecret          string
+	WebhookSignatureHeader string
+
+	// XMLNamespaces maps prefixes usable in XPath expressions to namespace
+	// URIs, in addition to the prefixes declared in each response.
+	XMLNamespaces map[string]string
+
+	// GRPCAddress is the gRPC server to call, defaulting to the host of the
+	// base URL. Methods are resolved from ProtoFiles, found in
+	// ProtoImportPaths, or by server reflection when there are none.
+	GRPCAddress      string
+	ProtoFiles       []string
+	ProtoImportPaths []string
+
+	// Timings collects the timing of every request when set, such as for a
+	// summary of the slowest requests.
+	Timings *Timings
+}
+
 func InitializeTestSuite(ctx *godog.TestSuiteContext) {
-	api := NewAPITest(os.Getenv("API_BASE_URL"))
-	InitializeScenario(api, ctx.ScenarioContext())
+	TestSuiteInitializer(Config{})(ctx)
+}
+
+// TestSuiteInitializer returns a godog suite initializer using cfg.
+func TestSuiteInitializer(cfg Config) func(ctx *godog.TestSuiteContext) {
+	return func(ctx *godog.TestSuiteContext) {
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = os.Getenv("API_BASE_URL")
+		}
+		api := NewAPITest(baseURL)
+		api.strict = cfg.Strict
+		api.seed = cfg.Seed
+		api.locale = cfg.Locale
+		api.generators = cfg.Generators
+		api.maskSecrets = cfg.MaskSecrets
+		api.webhookSecret = cfg.WebhookSecret
+		api.webhookSignatureHeader = cfg.WebhookSignatureHeader
+		api.xmlNamespaces = cfg.XMLNamespaces
+		api.grpcAddress = cfg.GRPCAddress
+		api.protoFiles = cfg.ProtoFiles
+		api.protoImportPaths = cfg.ProtoImportPaths
+		api.timings = cfg.Timings
+		for k, v := range cfg.Variables {
+			api.store[k] = normalizeValue(v)
+		}
+		ctx.AfterSuite(api.closeGRPCConns)
+		InitializeScenario(api, ctx.ScenarioContext())
+	}
 }
 
 func InitializeScenario(api *APITest, ctx *godog.ScenarioContext) {
-	// Request steps
-	ctx.Step(`^I send a "([^"]*)" request to "([^"]*)"$`, api.iSendRequestTo)
-	ctx.Step(`^I send a "([^"]*)" request to "([^"]*)" with payload:$`, api.iSendRequestToWithPayload)
-
-	// Response validation steps
-	ctx.Step(`^the response status should be (\d+)$`, api.theResponseStatusShouldBe)
-	ctx.Step(`^the response property "([^"]*)" should be (.*?)$`, api.theResponsePropertyShouldBe)
-	ctx.Step(`^the response property "([^"]*)" should not be empty$`, api.theResponsePropertyShouldNotBeEmpty)
-	ctx.Step(`^the response should match JSON:$`, api.theResponseShouldMatchJSON)
-	ctx.Step(`^the response should contain JSON:$`, api.theResponseShouldContainJSON)
-
-	// State management steps
-	ctx.Step(`^I store the response property "([^"]*)" as "([^"]*)"$`, api.iStoreTheResponsePropertyAs)
-	ctx.Step(`^I store the command output as "([^"]*)"$`, api.iStoreTheCommandOutputAs)
-	ctx.Step(`^I store "([^"]*)" as "([^"]*)"$`, api.iStoreAs)
-	ctx.Step(`^I set header "([^"]*)" to "([^"]*)"$`, api.iSetHeaderTo)
-	ctx.Step(`^I reset all variables$`, api.iResetAllVariables)
-	ctx.Step(`^I reset variables "([^"]*)"$`, api.iResetVariables)
-
-	// Data generation steps
-	ctx.Step(`^I generate fake data: "([^"]*)"$`, api.iGenerateFakeData)
-
-	// Command execution steps
-	ctx.Step(`^I execute command "([^"]*)"$`, api.iExecuteCommand)
-	ctx.Step(`^I execute command "([^"]*)" in directory "([^"]*)"$`, api.iExecuteCommandInDirectory)
-	ctx.Step(`^I execute command "([^"]*)" with timeout (\d+)$`, api.iExecuteCommandWithTimeout)
-	ctx.Step(`^the command output should match "([^"]*)"$`, api.theCommandOutputShouldMatch)
-	ctx.Step(`^the command output should contain "([^"]*)"$`, api.theCommandOutputShouldContain)
-
-	// Debugging steps
-	ctx.Step(`^I start debugging$`, api.iStartDebugging)
-	ctx.Step(`^I stop debugging$`, api.iStopDebugging)
+	ctx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+		api.reseed(sc)
+		api.lastRequest = nil
+		api.resetTiming()
+		api.scenario = sc.Name
+		api.graphQLVariables = nil
+		api.graphQLErrors = ""
+		api.grpc = nil
+		api.scenarioXMLNamespaces = nil
+		api.scenarioGRPCAddress = ""
+		api.scenarioProtoFiles = nil
+		return ctx, nil
+	})
+	ctx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		api.resetMocks()
+		api.resetWebhooks()
+		api.resetWebSocket()
+		api.resetEvents()
+		if err == nil {
+			return ctx, api.checkGraphQLErrors()
+		}
+		return ctx, nil
+	})
+
+	for _, step := range steps {
+		handler := step.handler(api)
+		if step.Category == CategoryRequests || step.Category == CategoryResponses {
+			handler = withCurl(api, handler)
+		}
+		ctx.Step(step.Pattern, handler)
+	}
 }
@@ -0,0 +1,355 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+	gherkin "github.com/cucumber/gherkin/go/v26"
+	messages "github.com/cucumber/messages/go/v21"
+)
+
+// placeholderPattern matches a ${...} placeholder.
+var placeholderPattern = regexp.MustCompile(`\${([^}]+)}`)
+
+// quotedPattern matches a quoted step argument.
+var quotedPattern = regexp.MustCompile(`"[^"]*"`)
+
+// parseErrorPattern matches a gherkin parse error.
+var parseErrorPattern = regexp.MustCompile(`^\((\d+):\d+\): (.*)$`)
+
+// LintIssue is a problem found in a feature file without running it.
+type LintIssue struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (i LintIssue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s", i.File, i.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+}
+
+// Lint checks the features under paths for undefined steps, ${...}
+// references to variables that are not set earlier in the scenario or its
+// background, and malformed JSON docstrings. Variables are treated as set
+// before every scenario.
+func Lint(paths []string, variables map[string]any) ([]LintIssue, error) {
+	features, _, err := LoadFeatures(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	linter := newLinter(variables)
+	var issues []LintIssue
+	for _, feature := range features {
+		original, err := os.ReadFile(feature.Name)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, linter.lintFeature(feature.Name, feature.Contents, original)...)
+	}
+
+	return issues, nil
+}
+
+type linter struct {
+	variables   map[string]any
+	patterns    []*regexp.Regexp
+	suggestions map[string]string
+}
+
+func newLinter(variables map[string]any) *linter {
+	l := &linter{
+		variables:   variables,
+		patterns:    make([]*regexp.Regexp, len(steps)),
+		suggestions: map[string]string{},
+	}
+	for i, step := range steps {
+		l.patterns[i] = regexp.MustCompile(step.Pattern)
+		l.suggestions[blankArguments(step.Syntax)] = step.Syntax
+	}
+	return l
+}
+
+// lintFeature checks the pickles of a feature. Line numbers refer to the
+// original file, which differs from contents when examples were expanded.
+func (l *linter) lintFeature(path string, contents, original []byte) []LintIssue {
+	ids := &messages.Incrementing{}
+	doc, err := gherkin.ParseGherkinDocument(bytes.NewReader(contents), ids.NewId)
+	if err != nil {
+		return parseIssues(path, err, sourceLines(contents, original))
+	}
+
+	stepLines := map[string]int{}
+	collectStepLines(doc.Feature, stepLines)
+	lines := sourceLines(contents, original)
+
+	var issues []LintIssue
+	seen := map[LintIssue]bool{}
+	report := func(line int, format string, args ...any) {
+		issue := LintIssue{File: path, Line: lines[line], Message: fmt.Sprintf(format, args...)}
+		if !seen[issue] {
+			seen[issue] = true
+			issues = append(issues, issue)
+		}
+	}
+
+	for _, pickle := range gherkin.Pickles(*doc, path, ids.NewId) {
+		api := &APITest{store: map[string]any{}, faker: gofakeit.New(0)}
+		for k, v := range l.variables {
+			api.store[k] = normalizeValue(v)
+		}
+
+		for _, step := range pickle.Steps {
+			line := stepLines[step.AstNodeIds[0]]
+
+			var docString, tableText string
+			if step.Argument != nil && step.Argument.DocString != nil {
+				docString = step.Argument.DocString.Content
+			}
+			if step.Argument != nil && step.Argument.DataTable != nil {
+				for _, row := range step.Argument.DataTable.Rows {
+					for _, cell := range row.Cells {
+						tableText += "\n" + cell.Value
+					}
+				}
+			}
+
+			def, args := l.match(step.Text)
+
+			for _, match := range placeholderPattern.FindAllString(step.Text+"\n"+docString+tableText, -1) {
+				if def != nil && namesDefinedVariable(def, args, match) {
+					continue
+				}
+				_, err := api.evalExpr(match[2 : len(match)-1])
+				if undefined, ok := err.(*undefinedVarError); ok && !strings.HasPrefix(undefined.name, "env.") {
+					report(line, "%s is not set earlier in the scenario: %s", match, api.describeExprError(err))
+				}
+			}
+
+			if def != nil && def.rawDocString {
+				docString = ""
+			}
+			if err := checkJSONDocString(docString); err != nil {
+				report(line, "malformed JSON docstring: %v", err)
+			}
+
+			if def == nil {
+				if suggestion, ok := l.suggest(step.Text); ok {
+					report(line, "undefined step: %s (did you mean: %s)", step.Text, suggestion)
+				} else {
+					report(line, "undefined step: %s", step.Text)
+				}
+				continue
+			}
+
+			if def.defines != nil {
+				for _, name := range def.defines(args) {
+					api.store[name] = nil
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// namesDefinedVariable reports whether placeholder is a whole argument
+// naming a variable the step defines, such as the webhook listener URL in
+// I listen for webhooks on "${webhook_url}".
+func namesDefinedVariable(def *StepDefinition, args []string, placeholder string) bool {
+	if def.defines == nil || !slices.Contains(args, placeholder) {
+		return false
+	}
+	return slices.Contains(def.defines(args), placeholder[2:len(placeholder)-1])
+}
+
+// match returns the first step whose pattern matches text, as godog does,
+// along with its arguments.
+func (l *linter) match(text string) (*StepDefinition, []string) {
+	for i, pattern := range l.patterns {
+		if args := pattern.FindStringSubmatch(text); args != nil {
+			return &steps[i], args[1:]
+		}
+	}
+	return nil, nil
+}
+
+// suggest returns the syntax of the registered step closest to text,
+// ignoring the contents of quoted arguments.
+func (l *linter) suggest(text string) (string, bool) {
+	candidates := make([]string, 0, len(l.suggestions))
+	for blanked := range l.suggestions {
+		candidates = append(candidates, blanked)
+	}
+
+	matches := closestMatches(blankArguments(text), candidates, 1)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return l.suggestions[matches[0]], true
+}
+
+func blankArguments(text string) string {
+	return quotedPattern.ReplaceAllString(text, `""`)
+}
+
+// checkJSONDocString validates docstrings that look like JSON, treating
+// placeholders as strings inside JSON strings and as values elsewhere.
+func checkJSONDocString(content string) error {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil
+	}
+
+	rendered := substituteJSON(trimmed, func(match string, inString bool) string {
+		if inString {
+			return ""
+		}
+		return "null"
+	})
+
+	var value any
+	return json.Unmarshal([]byte(rendered), &value)
+}
+
+func collectStepLines(feature *messages.Feature, lines map[string]int) {
+	if feature == nil {
+		return
+	}
+
+	addSteps := func(steps []*messages.Step) {
+		for _, step := range steps {
+			lines[step.Id] = int(step.Location.Line)
+		}
+	}
+
+	var addChildren func(background *messages.Background, scenario *messages.Scenario, rule *messages.Rule)
+	addChildren = func(background *messages.Background, scenario *messages.Scenario, rule *messages.Rule) {
+		switch {
+		case background != nil:
+			addSteps(background.Steps)
+		case scenario != nil:
+			addSteps(scenario.Steps)
+		case rule != nil:
+			for _, child := range rule.Children {
+				addChildren(child.Background, child.Scenario, nil)
+			}
+		}
+	}
+
+	for _, child := range feature.Children {
+		addChildren(child.Background, child.Scenario, child.Rule)
+	}
+}
+
+// parseIssues splits gherkin parse errors, reported as "(line:column): message",
+// into one issue per error.
+func parseIssues(path string, err error, lines []int) []LintIssue {
+	var issues []LintIssue
+	for _, msg := range strings.Split(err.Error(), "\n") {
+		match := parseErrorPattern.FindStringSubmatch(msg)
+		if match == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(match[1])
+		if line < len(lines) {
+			line = lines[line]
+		}
+		issues = append(issues, LintIssue{File: path, Line: line, Message: match[2]})
+	}
+
+	if len(issues) == 0 {
+		return []LintIssue{{File: path, Message: err.Error()}}
+	}
+	return issues
+}
+
+// sourceLines maps each 1-based line of contents to its line in original.
+// Lines added by ExpandExamples map to the last original line before them.
+func sourceLines(contents, original []byte) []int {
+	expanded := strings.Split(string(contents), "\n")
+	source := strings.Split(string(original), "\n")
+
+	lines := make([]int, len(expanded)+1)
+	j := 0
+	for i, line := range expanded {
+		if j < len(source) && (line == source[j] || line == source[j]+" [<"+rowColumn+">]") {
+			j++
+		}
+		lines[i+1] = j
+	}
+	return lines
+}
+
+// definesArg reports the variable named by the i-th step argument.
+func definesArg(i int) func(args []string) []string {
+	return func(args []string) []string {
+		return []string{args[i]}
+	}
+}
+
+// definesFakeData reports the variables of the data specification in the
+// i-th step argument.
+func definesFakeData(i int) func(args []string) []string {
+	return func(args []string) []string {
+		var names []string
+		for _, pair := range splitByCommaOutsideBrackets(args[i]) {
+			if name, _, ok := strings.Cut(pair, "="); ok {
+				names = append(names, strings.TrimSpace(name))
+			}
+		}
+		return names
+	}
+}
+
+// definesMock reports the URL variable of the mock named by the first step
+// argument.
+func definesMock(args []string) []string {
+	return []string{mockURLVariable(args[0])}
+}
+
+// definesWebhookVariable reports the variable the webhook listener URL is
+// stored in.
+func definesWebhookVariable(args []string) []string {
+	return []string{webhookVariable(args[0])}
+}
+
+// definesResponseTime reports the variables a request stores its timing in.
+func definesResponseTime(args []string) []string {
+	return responseTimeVariables
+}
+
+func definesVariablesFile(args []string) []string {
+	vars, err := LoadVariables(args[0])
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	return names
+}
+
+func definesEnvironmentVariables(args []string) []string {
+	var names []string
+	for _, name := range strings.Split(args[0], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
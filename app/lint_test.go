@@ -0,0 +1,99 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ids.csv"), []byte("id\n1\n2\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+
+	feature := `Feature: Users
+
+  Background:
+    Given I store "acme" as "tenant"
+
+  @examples(file=ids.csv)
+  Scenario Outline: Fetch user
+    When I send a "GET" request to "/${tenant}/users/<id>"
+
+  Scenario: Create user
+    Given I generate fake data: "email={email}, name={firstname}"
+    And I store command output as "result"
+    When I send a "POST" request to "/users" with payload:
+      """
+      {"email": "${email}", "name": ${name}, "token": "${token}", "base": "${base_url}"}
+      """
+    And I store the response property "id" as "user_id"
+    Then the response property "id" should be "${user_id}"
+    And the response property "role" should be "${role:-admin}"
+    And I send a "PUT" request to "/users/${usr_id}" with payload:
+      """
+      {"email": "${email}",}
+      """
+`
+	if err := os.WriteFile(filepath.Join(dir, "users.feature"), []byte(feature), 0o644); err != nil {
+		t.Fatalf("Failed to write feature: %v", err)
+	}
+
+	issues, err := Lint([]string{dir}, map[string]any{"base_url": "http://localhost"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, strings.TrimPrefix(issue.String(), dir+string(filepath.Separator)))
+	}
+
+	expected := []string{
+		`users.feature:12: undefined step: I store command output as "result" (did you mean: I store the command output as "VARIABLE_NAME")`,
+		`users.feature:13: ${token} is not set earlier in the scenario: undefined variable "token"`,
+		`users.feature:20: ${usr_id} is not set earlier in the scenario: undefined variable "usr_id" (did you mean "user_id"?)`,
+		`users.feature:20: malformed JSON docstring: invalid character '}' looking for beginning of object key string`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected issues:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestLintParseError(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.feature"), []byte("Feature: Broken\n  Scenario: One\n    Given I start debugging\n    this is not a step\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write feature: %v", err)
+	}
+
+	issues, err := Lint([]string{dir}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(issues) != 1 || issues[0].Line != 4 || !strings.Contains(issues[0].Message, "this is not a step") {
+		t.Errorf("Expected a parse error on line 4, got %v", issues)
+	}
+}
+
+func TestLintResponseTimeVariables(t *testing.T) {
+	dir := t.TempDir()
+	feature := `Feature: Timing
+  Scenario: Search
+    When I send a "GET" request to "/search"
+    Then the response property "elapsed" should be ${response.time_ms}
+    And I store "${response.ttfb_ms}" as "ttfb"
+`
+	if err := os.WriteFile(filepath.Join(dir, "timing.feature"), []byte(feature), 0o644); err != nil {
+		t.Fatalf("Failed to write feature: %v", err)
+	}
+
+	issues, err := Lint([]string{dir}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected response time variables to be set by requests, got %v", issues)
+	}
+}
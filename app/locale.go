@@ -0,0 +1,117 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// localeData holds the values used to localize gofakeit tags. gofakeit only
+// ships English data, so locales override the tags that visibly differ
+// between countries: names, addresses and phone numbers.
+type localeData struct {
+	firstNames  []string
+	lastNames   []string
+	streets     []string
+	cities      []string
+	states      []string
+	country     string
+	countryCode string
+	zipFormat   string
+	phoneFormat string
+	phoneIntl   string
+	// streetFirst places the street name before the house number.
+	streetFirst bool
+}
+
+var locales = map[string]localeData{
+	"de": {
+		firstNames:  []string{"Lukas", "Leon", "Finn", "Jonas", "Paul", "Felix", "Maximilian", "Emma", "Mia", "Hannah", "Sophie", "Lea", "Anna", "Lena", "Marie", "Katharina"},
+		lastNames:   []string{"Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Meyer", "Wagner", "Becker", "Schulz", "Hoffmann", "Schäfer", "Koch", "Bauer", "Richter", "Klein", "Wolf"},
+		streets:     []string{"Hauptstraße", "Schulstraße", "Gartenstraße", "Bahnhofstraße", "Dorfstraße", "Bergstraße", "Birkenweg", "Lindenstraße", "Kirchstraße", "Waldstraße", "Ringstraße", "Goethestraße"},
+		cities:      []string{"Berlin", "Hamburg", "München", "Köln", "Frankfurt am Main", "Stuttgart", "Düsseldorf", "Leipzig", "Dortmund", "Essen", "Bremen", "Dresden", "Hannover", "Nürnberg"},
+		states:      []string{"Baden-Württemberg", "Bayern", "Berlin", "Brandenburg", "Bremen", "Hamburg", "Hessen", "Niedersachsen", "Nordrhein-Westfalen", "Rheinland-Pfalz", "Saarland", "Sachsen", "Thüringen"},
+		country:     "Deutschland",
+		countryCode: "DE",
+		zipFormat:   "#####",
+		phoneFormat: "0### #######",
+		phoneIntl:   "+49 ### #######",
+		streetFirst: true,
+	},
+	"fr": {
+		firstNames:  []string{"Gabriel", "Louis", "Raphaël", "Jules", "Adam", "Lucas", "Hugo", "Léo", "Jade", "Louise", "Emma", "Alice", "Chloé", "Lina", "Léa", "Manon"},
+		lastNames:   []string{"Martin", "Bernard", "Thomas", "Petit", "Robert", "Richard", "Durand", "Dubois", "Moreau", "Laurent", "Simon", "Michel", "Lefèvre", "Leroy", "Roux", "David"},
+		streets:     []string{"rue de la Paix", "rue Victor Hugo", "avenue Jean Jaurès", "rue de la République", "boulevard Voltaire", "rue Pasteur", "place de la Mairie", "rue du Moulin", "allée des Tilleuls", "rue de l'Église"},
+		cities:      []string{"Paris", "Marseille", "Lyon", "Toulouse", "Nice", "Nantes", "Strasbourg", "Montpellier", "Bordeaux", "Lille", "Rennes", "Reims"},
+		states:      []string{"Auvergne-Rhône-Alpes", "Bretagne", "Normandie", "Occitanie", "Grand Est", "Hauts-de-France", "Île-de-France", "Nouvelle-Aquitaine", "Provence-Alpes-Côte d'Azur"},
+		country:     "France",
+		countryCode: "FR",
+		zipFormat:   "#####",
+		phoneFormat: "0# ## ## ## ##",
+		phoneIntl:   "+33 # ## ## ## ##",
+	},
+}
+
+// localeTag returns a generator for a tag in the given locale, if the locale
+// overrides it. Tag names are matched case-insensitively like gofakeit's.
+func localeTag(locale, tag string) (func(f *gofakeit.Faker) string, bool, error) {
+	if locale == "" || strings.EqualFold(locale, "en") {
+		return nil, false, nil
+	}
+
+	data, ok := locales[strings.ToLower(locale)]
+	if !ok {
+		return nil, false, fmt.Errorf("unsupported locale %q", locale)
+	}
+
+	pick := func(values []string) func(f *gofakeit.Faker) string {
+		return func(f *gofakeit.Faker) string {
+			return values[f.IntN(len(values))]
+		}
+	}
+
+	street := func(f *gofakeit.Faker) string {
+		name := pick(data.streets)(f)
+		number := fmt.Sprintf("%d", f.IntRange(1, 199))
+		if data.streetFirst {
+			return name + " " + number
+		}
+		return number + " " + name
+	}
+
+	switch strings.ToLower(tag) {
+	case "firstname":
+		return pick(data.firstNames), true, nil
+	case "lastname":
+		return pick(data.lastNames), true, nil
+	case "name":
+		return func(f *gofakeit.Faker) string {
+			return pick(data.firstNames)(f) + " " + pick(data.lastNames)(f)
+		}, true, nil
+	case "streetname":
+		return pick(data.streets), true, nil
+	case "street":
+		return street, true, nil
+	case "city":
+		return pick(data.cities), true, nil
+	case "state":
+		return pick(data.states), true, nil
+	case "zip":
+		return func(f *gofakeit.Faker) string { return f.Numerify(data.zipFormat) }, true, nil
+	case "country":
+		return func(*gofakeit.Faker) string { return data.country }, true, nil
+	case "countryabr":
+		return func(*gofakeit.Faker) string { return data.countryCode }, true, nil
+	case "phone":
+		return func(f *gofakeit.Faker) string { return f.Numerify(data.phoneFormat) }, true, nil
+	case "phoneformatted":
+		return func(f *gofakeit.Faker) string { return f.Numerify(data.phoneIntl) }, true, nil
+	case "address":
+		return func(f *gofakeit.Faker) string {
+			return fmt.Sprintf("%s, %s %s, %s", street(f), f.Numerify(data.zipFormat), pick(data.cities)(f), data.country)
+		}, true, nil
+	default:
+		return nil, false, nil
+	}
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// mockServer is an in-process HTTP server standing in for a third-party
+// API during a scenario.
+type mockServer struct {
+	server   *http.Server
+	url      string
+	mu       sync.Mutex
+	routes   map[string]mockResponse
+	received []mockRequest
+}
+
+type mockResponse struct {
+	status int
+	body   string
+}
+
+type mockRequest struct {
+	method string
+	path   string
+	body   string
+}
+
+func newMockServer() (*mockServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start mock server: %w", err)
+	}
+
+	m := &mockServer{
+		url:    "http://" + listener.Addr().String(),
+		routes: map[string]mockResponse{},
+	}
+	m.server = &http.Server{Handler: m}
+	go m.server.Serve(listener)
+
+	return m, nil
+}
+
+func (m *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	m.mu.Lock()
+	m.received = append(m.received, mockRequest{method: r.Method, path: r.URL.Path, body: string(body)})
+	response, ok := m.routes[r.Method+" "+r.URL.Path]
+	m.mu.Unlock()
+
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"error": %s}`, marshalJSON("no mock response for "+r.Method+" "+r.URL.Path))
+		return
+	}
+
+	if isJSONPayload(response.body) {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(response.status)
+	io.WriteString(w, response.body)
+}
+
+// mock returns the named mock server, starting it and exposing its URL as
+// ${mock.NAME.url} on first use.
+func (a *APITest) mock(name string) (*mockServer, error) {
+	if m, ok := a.mocks[name]; ok {
+		return m, nil
+	}
+
+	m, err := newMockServer()
+	if err != nil {
+		return nil, err
+	}
+	if a.mocks == nil {
+		a.mocks = map[string]*mockServer{}
+	}
+	a.mocks[name] = m
+
+	a.store[mockURLVariable(name)] = m.url
+
+	if a.debug {
+		fmt.Printf("Started mock %s on %s\n", name, m.url)
+	}
+
+	return m, nil
+}
+
+// resetMocks stops every mock server so each scenario starts without any.
+func (a *APITest) resetMocks() {
+	for name, m := range a.mocks {
+		m.server.Close()
+		delete(a.store, mockURLVariable(name))
+	}
+	a.mocks = nil
+}
+
+// mockURLVariable names the store variable holding a mock's URL.
+func mockURLVariable(name string) string {
+	return "mock." + name + ".url"
+}
+
+func (a *APITest) theMockRespondsToWithStatus(name, route string, status int) error {
+	return a.theMockRespondsToWithStatusAndBody(name, route, status, "")
+}
+
+func (a *APITest) theMockRespondsToWithStatusAndBody(name, route string, status int, body string) error {
+	route, err := a.replaceVars(route)
+	if err != nil {
+		return err
+	}
+
+	method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+	if !ok || !strings.HasPrefix(path, "/") {
+		return fmt.Errorf(`invalid mock route %q, expected "METHOD /path"`, route)
+	}
+
+	if isJSONPayload(body) {
+		body, err = a.renderJSON(body)
+	} else {
+		body, err = a.replaceVars(body)
+	}
+	if err != nil {
+		return err
+	}
+
+	m, err := a.mock(name)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.routes[strings.ToUpper(method)+" "+path] = mockResponse{status: status, body: body}
+	m.mu.Unlock()
+
+	if a.debug {
+		fmt.Printf("Mock %s responds to %s %s with status %d\n", name, strings.ToUpper(method), path, status)
+	}
+
+	return nil
+}
+
+func (a *APITest) theMockShouldHaveReceivedRequestsTo(name string, count int, method, path string) error {
+	return a.theMockShouldHaveReceivedRequestsToWithJSONContaining(name, count, method, path, "")
+}
+
+func (a *APITest) theMockShouldHaveReceivedRequestsToWithJSONContaining(name string, count int, method, path, expected string) error {
+	m, ok := a.mocks[name]
+	if !ok {
+		return fmt.Errorf("mock %s has not been set up in this scenario", name)
+	}
+
+	path, err := a.replaceVars(path)
+	if err != nil {
+		return err
+	}
+
+	var subset map[string]any
+	if expected != "" {
+		templated, err := a.replaceVars(expected)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(templated), &subset); err != nil {
+			return fmt.Errorf("invalid expected JSON: %w", err)
+		}
+	}
+
+	m.mu.Lock()
+	received := append([]mockRequest(nil), m.received...)
+	m.mu.Unlock()
+
+	matched := 0
+	var mismatches []string
+	for _, req := range received {
+		if !strings.EqualFold(req.method, method) || req.path != path {
+			continue
+		}
+		if subset != nil {
+			var actual map[string]any
+			if err := json.Unmarshal([]byte(req.body), &actual); err != nil {
+				mismatches = append(mismatches, fmt.Sprintf("body is not a JSON object: %s", req.body))
+				continue
+			}
+			if err := containsSubset(actual, subset); err != nil {
+				mismatches = append(mismatches, err.Error())
+				continue
+			}
+		}
+		matched++
+	}
+
+	if matched != count {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "expected mock %s to receive %d %s request(s) to %s but got %d", name, count, strings.ToUpper(method), path, matched)
+		for _, mismatch := range mismatches {
+			fmt.Fprintf(&sb, "\n  not matching: %s", mismatch)
+		}
+		if len(received) == 0 {
+			sb.WriteString("\n  no requests were received")
+		} else {
+			sb.WriteString("\n  received:")
+			for _, req := range received {
+				fmt.Fprintf(&sb, "\n    %s %s %s", req.method, req.path, req.body)
+			}
+		}
+		return errors.New(sb.String())
+	}
+
+	if a.debug {
+		fmt.Printf("Mock %s received %d %s request(s) to %s\n", name, matched, strings.ToUpper(method), path)
+	}
+
+	return nil
+}
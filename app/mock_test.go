@@ -0,0 +1,60 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMockServer(t *testing.T) {
+	apiTest := NewAPITest("")
+	apiTest.store["charge_id"] = "ch_1"
+	defer apiTest.resetMocks()
+
+	err := apiTest.theMockRespondsToWithStatusAndBody("payments", "POST /charges", 201, `{"id": "${charge_id}"}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	url, err := apiTest.replaceVars("${mock.payments.url}")
+	if err != nil || !strings.HasPrefix(url, "http://127.0.0.1:") {
+		t.Fatalf("Expected the mock URL to be stored, got %q, %v", url, err)
+	}
+
+	if err := apiTest.sendRequest("POST", url+"/charges", `{"amount": 1000, "currency": "usd"}`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if apiTest.response.StatusCode != 201 || apiTest.responseBody != `{"id": "ch_1"}` {
+		t.Errorf("Expected the stubbed response, got %d %s", apiTest.response.StatusCode, apiTest.responseBody)
+	}
+
+	if err := apiTest.sendRequest("GET", url+"/refunds", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if apiTest.response.StatusCode != 404 {
+		t.Errorf("Expected status 404 for an unstubbed route, got %d", apiTest.response.StatusCode)
+	}
+
+	if err := apiTest.theMockShouldHaveReceivedRequestsTo("payments", 1, "post", "/charges"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theMockShouldHaveReceivedRequestsToWithJSONContaining("payments", 1, "POST", "/charges", `{"amount": 1000}`); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	err = apiTest.theMockShouldHaveReceivedRequestsToWithJSONContaining("payments", 1, "POST", "/charges", `{"amount": 5}`)
+	if err == nil || !strings.Contains(err.Error(), "but got 0") || !strings.Contains(err.Error(), "GET /refunds") {
+		t.Errorf("Expected a mismatch listing the received requests, got %v", err)
+	}
+
+	if err := apiTest.theMockRespondsToWithStatus("payments", "/charges", 200); err == nil {
+		t.Errorf("Expected an error for a route without a method")
+	}
+
+	apiTest.resetMocks()
+	if _, ok := apiTest.store["mock.payments.url"]; ok {
+		t.Errorf("Expected the mock URLs to be removed from the store")
+	}
+	if err := apiTest.theMockShouldHaveReceivedRequestsTo("payments", 0, "POST", "/charges"); err == nil {
+		t.Errorf("Expected an error for a mock that was reset")
+	}
+}
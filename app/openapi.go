@@ -0,0 +1,444 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// openAPIMethods lists the operations of a path item in the order their
+// scenarios are generated.
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// payloadVariable stores the generated request body in generated scenarios.
+const payloadVariable = "payload"
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+type openAPIOperation struct {
+	method string
+	path   string
+	op     map[string]any
+	item   map[string]any
+}
+
+// GenerateFeatures creates starter feature files from an OpenAPI 3 document,
+// keyed by file name. Operations are grouped into one feature per tag, or per
+// first path segment for untagged operations. specPath is written into the
+// schema assertions, so it should be relative to where rbdd runs.
+func GenerateFeatures(specPath string) (map[string]string, error) {
+	doc, err := loadSchemaFile(specPath)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, ok := doc["paths"].(map[string]any)
+	if !ok || len(paths) == 0 {
+		return nil, fmt.Errorf("no paths found in %s", specPath)
+	}
+
+	groups := map[string][]openAPIOperation{}
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		item, _ := paths[path].(map[string]any)
+		item, err := resolveRef(doc, item)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", path, err)
+		}
+
+		for _, method := range openAPIMethods {
+			if op, ok := item[method].(map[string]any); ok {
+				group := operationGroup(path, op)
+				groups[group] = append(groups[group], openAPIOperation{method, path, op, item})
+			}
+		}
+	}
+
+	gen := &featureGenerator{doc: doc, specPath: specPath, faker: gofakeit.New(1)}
+	features := map[string]string{}
+	for _, group := range slices.Sorted(maps.Keys(groups)) {
+		ops := groups[group]
+		content, err := gen.feature(group, ops)
+		if err != nil {
+			return nil, err
+		}
+		features[featureFileName(group)] = content
+	}
+
+	return features, nil
+}
+
+// operationGroup returns the first tag of an operation, or the first static
+// segment of its path.
+func operationGroup(path string, op map[string]any) string {
+	if tags, ok := op["tags"].([]any); ok && len(tags) > 0 {
+		if tag, ok := tags[0].(string); ok && tag != "" {
+			return tag
+		}
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" && !strings.HasPrefix(segment, "{") {
+			return segment
+		}
+	}
+	return "root"
+}
+
+func featureFileName(group string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(group), "_"), "_")
+	if slug == "" {
+		slug = "api"
+	}
+	return slug + ".feature"
+}
+
+// featureGenerator writes the scenarios of an OpenAPI document. Its faker is
+// seeded so regenerating from the same document gives the same output.
+type featureGenerator struct {
+	doc      map[string]any
+	specPath string
+	faker    *gofakeit.Faker
+}
+
+func (g *featureGenerator) feature(name string, ops []openAPIOperation) (string, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Feature: %s\n", name)
+	if description := g.tagDescription(name); description != "" {
+		for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+			fmt.Fprintf(&sb, "  %s\n", strings.TrimSpace(line))
+		}
+	}
+	fmt.Fprintf(&sb, "  Generated from %s. Review the payloads and expected statuses before relying on it.\n", g.specPath)
+
+	for _, o := range ops {
+		sb.WriteString("\n")
+		if err := g.scenario(&sb, o); err != nil {
+			return "", fmt.Errorf("%s %s: %w", strings.ToUpper(o.method), o.path, err)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func (g *featureGenerator) tagDescription(name string) string {
+	tags, _ := g.doc["tags"].([]any)
+	for _, tag := range tags {
+		if t, ok := tag.(map[string]any); ok && t["name"] == name {
+			description, _ := t["description"].(string)
+			return description
+		}
+	}
+	return ""
+}
+
+func (g *featureGenerator) scenario(sb *strings.Builder, o openAPIOperation) error {
+	name, _ := o.op["summary"].(string)
+	if name == "" {
+		name, _ = o.op["operationId"].(string)
+	}
+	if name == "" {
+		name = strings.ToUpper(o.method) + " " + o.path
+	}
+	w := newScenarioWriter(sb, name)
+
+	params, err := g.parameters(o)
+	if err != nil {
+		return err
+	}
+
+	endpoint := o.path
+	var specs, query []string
+	for _, param := range params {
+		name, _ := param["name"].(string)
+		switch param["in"] {
+		case "path":
+			endpoint = strings.ReplaceAll(endpoint, "{"+name+"}", "${"+name+"}")
+		case "query":
+			if param["required"] != true {
+				continue
+			}
+			query = append(query, name+"=${urlencode("+name+")}")
+		default:
+			continue
+		}
+		specs = append(specs, name+"="+g.parameterValue(param))
+	}
+	if len(query) > 0 {
+		endpoint += "?" + strings.Join(query, "&")
+	}
+
+	if len(specs) > 0 {
+		w.step("Given", `I generate fake data: "%s"`, strings.Join(specs, ", "))
+	}
+
+	if body := g.requestSchema(o.op); body != nil {
+		template, err := json.MarshalIndent(g.template(body, "", 0), "", "  ")
+		if err != nil {
+			return err
+		}
+		w.step("Given", `I generate a fake "%s" from JSON template:`, payloadVariable)
+		w.docString(string(template))
+		w.step("When", `I send a "%s" request to "%s" with payload:`, strings.ToUpper(o.method), endpoint)
+		w.docString("${" + payloadVariable + "}")
+	} else {
+		w.step("When", `I send a "%s" request to "%s"`, strings.ToUpper(o.method), endpoint)
+	}
+
+	code, pointer := g.successResponse(o)
+	w.step("Then", "the response status should be %s", code)
+	if pointer != "" {
+		w.step("Then", `the response should match schema "%s#%s"`, g.specPath, pointer)
+	}
+
+	return nil
+}
+
+// parameters returns the path item's and operation's parameters, with
+// operation parameters overriding path item ones of the same name.
+func (g *featureGenerator) parameters(o openAPIOperation) ([]map[string]any, error) {
+	var params []map[string]any
+	index := map[string]int{}
+
+	for _, list := range []any{o.item["parameters"], o.op["parameters"]} {
+		for _, raw := range schemaList(list) {
+			param, err := resolveRef(g.doc, raw)
+			if err != nil {
+				return nil, err
+			}
+			key := fmt.Sprint(param["in"], ":", param["name"])
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+
+	return params, nil
+}
+
+// parameterValue returns a fake data pattern for a parameter, preferring
+// its example.
+func (g *featureGenerator) parameterValue(param map[string]any) string {
+	if example, ok := param["example"]; ok {
+		return formatValue(example)
+	}
+
+	schema, _ := param["schema"].(map[string]any)
+	name, _ := param["name"].(string)
+	return formatValue(g.template(schema, name, 0))
+}
+
+// requestSchema returns the JSON schema of an operation's request body.
+func (g *featureGenerator) requestSchema(op map[string]any) map[string]any {
+	body, ok := op["requestBody"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	body, err := resolveRef(g.doc, body)
+	if err != nil {
+		return nil
+	}
+
+	content, _ := body["content"].(map[string]any)
+	if mediaType := jsonMediaType(content); mediaType != "" {
+		media, _ := content[mediaType].(map[string]any)
+		schema, _ := media["schema"].(map[string]any)
+		return schema
+	}
+	return nil
+}
+
+// successResponse returns the lowest 2xx status of an operation and a JSON
+// pointer to its JSON response schema, if any.
+func (g *featureGenerator) successResponse(o openAPIOperation) (string, string) {
+	responses, _ := o.op["responses"].(map[string]any)
+
+	code := ""
+	for _, c := range slices.Sorted(maps.Keys(responses)) {
+		if len(c) == 3 && c[0] == '2' && strings.Trim(c, "0123456789") == "" {
+			code = c
+			break
+		}
+	}
+	if code == "" {
+		return "200", ""
+	}
+
+	response, _ := responses[code].(map[string]any)
+	pointer := "/paths/" + escapePointer(o.path) + "/" + o.method + "/responses/" + code
+	if ref, ok := response["$ref"].(string); ok {
+		pointer = strings.TrimPrefix(ref, "#")
+	}
+
+	response, err := resolveRef(g.doc, response)
+	if err != nil {
+		return code, ""
+	}
+	content, _ := response["content"].(map[string]any)
+	mediaType := jsonMediaType(content)
+	if mediaType == "" {
+		return code, ""
+	}
+	if media, _ := content[mediaType].(map[string]any); media["schema"] == nil {
+		return code, ""
+	}
+
+	return code, pointer + "/content/" + escapePointer(mediaType) + "/schema"
+}
+
+// template builds a fake data template for a schema, using gofakeit tags for
+// generated values and the schema's examples where given. Read-only
+// properties are left out since they are not sent in requests.
+func (g *featureGenerator) template(schema map[string]any, field string, depth int) any {
+	if schema == nil || depth > schemaDepthLimit {
+		return nil
+	}
+	schema, err := resolveRef(g.doc, schema)
+	if err != nil {
+		return nil
+	}
+
+	if example, ok := schema["example"]; ok {
+		return example
+	}
+	if tag, ok := schema["x-faker"].(string); ok {
+		return tag
+	}
+	if value, ok := schema["const"]; ok {
+		return value
+	}
+	if values, ok := schema["enum"].([]any); ok && len(values) > 0 {
+		return enumTemplate(values)
+	}
+	if all := schemaList(schema["allOf"]); len(all) > 0 {
+		merged := map[string]any{}
+		for _, part := range all {
+			if obj, ok := g.template(part, field, depth+1).(map[string]any); ok {
+				maps.Copy(merged, obj)
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options := schemaList(schema[key]); len(options) > 0 {
+			return g.template(options[0], field, depth+1)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		result := map[string]any{}
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range slices.Sorted(maps.Keys(properties)) {
+			raw := properties[name]
+			prop, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			if resolved, err := resolveRef(g.doc, prop); err == nil && resolved["readOnly"] == true {
+				continue
+			}
+			result[name] = g.template(prop, name, depth+1)
+		}
+		return result
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		if items == nil {
+			items = map[string]any{"type": "string"}
+		}
+		lo, hi := 1, 3
+		if n, ok := toNumber(schema["minItems"]); ok {
+			lo, hi = int(n), max(hi, int(n))
+		}
+		if n, ok := toNumber(schema["maxItems"]); ok {
+			hi, lo = int(n), min(lo, int(n))
+		}
+		return []any{fmt.Sprintf("{repeat:%d,%d}", lo, hi), g.template(items, field, depth+1)}
+	case "integer":
+		lo, hi := schemaRange(schema, 1, 1000)
+		return fmt.Sprintf("{number:%d,%d}", int(math.Ceil(lo)), int(math.Floor(hi)))
+	case "number":
+		lo, hi := schemaRange(schema, 1, 1000)
+		return fmt.Sprintf("{float64range:%v,%v}", lo, hi)
+	case "boolean":
+		return "{bool}"
+	case "null":
+		return nil
+	default:
+		return g.stringTemplate(schema, field)
+	}
+}
+
+func (g *featureGenerator) stringTemplate(schema map[string]any, field string) string {
+	// Patterns rarely survive as gofakeit tag parameters, so a matching
+	// value is generated up front instead.
+	if pattern, ok := schema["pattern"].(string); ok {
+		return g.faker.Regex(strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$"))
+	}
+
+	switch schema["format"] {
+	case "email":
+		return "{email}"
+	case "uuid":
+		return "{uuid}"
+	case "date-time":
+		return "{date:RFC3339}"
+	case "date":
+		return "{date:2006-01-02}"
+	case "uri", "url":
+		return "{url}"
+	case "hostname":
+		return "{domainname}"
+	case "ipv4":
+		return "{ipv4address}"
+	case "ipv6":
+		return "{ipv6address}"
+	case "password":
+		return fieldTags["password"]
+	}
+
+	if tag, ok := fieldTags[strings.ToLower(field)]; ok {
+		return tag
+	}
+
+	lo, hi := schemaLength(schema)
+	if n := min(max(lo, 10), hi); n > 0 {
+		return fmt.Sprintf("{lettern:%d}", n)
+	}
+	return ""
+}
+
+// enumTemplate picks a random value from string enums that can be written as
+// a gofakeit tag, and the first value otherwise.
+func enumTemplate(values []any) any {
+	options := make([]string, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok || strings.ContainsAny(s, ",[]{}") {
+			return values[0]
+		}
+		options = append(options, s)
+	}
+	return "{randomstring:[" + strings.Join(options, ",") + "]}"
+}
+
+// jsonMediaType returns the first JSON media type of a content map.
+func jsonMediaType(content map[string]any) string {
+	for _, mediaType := range slices.Sorted(maps.Keys(content)) {
+		if strings.Contains(mediaType, "json") {
+			return mediaType
+		}
+	}
+	return ""
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
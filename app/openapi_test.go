@@ -0,0 +1,158 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+const petsSpec = `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+tags:
+  - name: Pets
+    description: Everything about pets
+paths:
+  /pets:
+    get:
+      tags: [Pets]
+      summary: List pets
+      parameters:
+        - {name: limit, in: query, required: true, schema: {type: integer, maximum: 50}}
+        - {name: cursor, in: query, schema: {type: string}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {type: array, items: {$ref: "#/components/schemas/Pet"}}
+    post:
+      tags: [Pets]
+      summary: Create a pet
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/Pet"}
+      responses:
+        "201": {$ref: "#/components/responses/PetCreated"}
+        "400": {description: bad}
+  /pets/{petId}:
+    parameters:
+      - {name: petId, in: path, required: true, schema: {type: string, format: uuid}}
+    delete:
+      tags: [Pets]
+      operationId: deletePet
+      responses:
+        "204": {description: gone}
+  /health:
+    get:
+      responses:
+        "200": {description: ok}
+components:
+  responses:
+    PetCreated:
+      description: created
+      content:
+        application/json:
+          schema: {$ref: "#/components/schemas/Pet"}
+  schemas:
+    Pet:
+      type: object
+      required: [name, kind]
+      properties:
+        id: {type: string, format: uuid, readOnly: true}
+        name: {type: string}
+        kind: {type: string, enum: [cat, dog]}
+        age: {type: integer, minimum: 0, maximum: 30}
+        code: {type: string, pattern: "^[A-Z]{3}-[0-9]{2}$"}
+        ref: {type: string, pattern: "^[a-z]{8}$"}
+        tags: {type: array, items: {type: string}, maxItems: 2}
+`
+
+func TestGenerateFeatures(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "api.yaml")
+	if err := os.WriteFile(spec, []byte(petsSpec), 0o644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	features, err := GenerateFeatures(spec)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(features) != 2 || features["pets.feature"] == "" || features["health.feature"] == "" {
+		t.Fatalf("Expected pets.feature and health.feature, got %v", features)
+	}
+
+	pets := features["pets.feature"]
+	for _, expected := range []string{
+		"Feature: Pets\n  Everything about pets\n",
+		"  Scenario: List pets\n    Given I generate fake data: \"limit={number:1,50}\"\n    When I send a \"GET\" request to \"/pets?limit=${urlencode(limit)}\"\n    Then the response status should be 200\n",
+		`And the response should match schema "` + spec + `#/paths/~1pets/get/responses/200/content/application~1json/schema"`,
+		`"kind": "{randomstring:[cat,dog]}"`,
+		`When I send a "POST" request to "/pets" with payload:`,
+		`Then the response status should be 201`,
+		`And the response should match schema "` + spec + `#/components/responses/PetCreated/content/application~1json/schema"`,
+		"  Scenario: deletePet\n    Given I generate fake data: \"petId={uuid}\"\n    When I send a \"DELETE\" request to \"/pets/${petId}\"\n    Then the response status should be 204\n",
+	} {
+		if !strings.Contains(pets, expected) {
+			t.Errorf("Expected pets.feature to contain %q, got:\n%s", expected, pets)
+		}
+	}
+	if strings.Contains(pets, `"id"`) || strings.Contains(pets, "cursor") {
+		t.Errorf("Expected read-only properties and optional parameters to be left out, got:\n%s", pets)
+	}
+
+	// Patterns draw from the seeded faker, so the output must not depend
+	// on map order.
+	for range 10 {
+		again, err := GenerateFeatures(spec)
+		if err != nil || again["pets.feature"] != pets || again["health.feature"] != features["health.feature"] {
+			t.Fatalf("Expected the same features on every run, got:\n%s", again["pets.feature"])
+		}
+	}
+
+	for name, content := range features {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write feature: %v", err)
+		}
+	}
+	issues, err := Lint([]string{dir}, nil)
+	if err != nil || len(issues) > 0 {
+		t.Errorf("Expected generated features to lint cleanly, got %v, %v", issues, err)
+	}
+}
+
+func TestFeatureGeneratorTemplateMatchesSchema(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "api.yaml")
+	if err := os.WriteFile(spec, []byte(petsSpec), 0o644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	schema, root, err := loadSchema(spec + "#/components/schemas/Pet")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	gen := &featureGenerator{doc: root, faker: gofakeit.New(1)}
+	template, err := json.Marshal(gen.template(schema, "", 0))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	apiTest := NewAPITest("")
+	for range 20 {
+		if err := apiTest.iGenerateAFakeFromJSONTemplate("pet", string(template)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		validator := &schemaValidator{root: root}
+		if errs := validator.validate(apiTest.store["pet"], schema, "", 0); len(errs) > 0 {
+			t.Errorf("Expected generated pet %v to match its schema, got %v", apiTest.store["pet"], errs)
+		}
+	}
+}
@@ -0,0 +1,526 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jsChain matches a JavaScript accessor chain such as
+// pm.response.json().items[0]["id"].
+const jsChain = `\w+(?:\.\w+(?:\(\))?|\[\d+\]|\["[^"]+"\]|\['[^']+'\])+`
+
+var (
+	// postmanVariable matches a {{variable}} reference.
+	postmanVariable = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+	postmanStatus = []*regexp.Regexp{
+		regexp.MustCompile(`^pm\.response\.to\.have\.status\((\d+)\)$`),
+		regexp.MustCompile(`^pm\.expect\(pm\.response\.code\)\.to\.(?:eql|equal|be\.equal|be)\((\d+)\)$`),
+		regexp.MustCompile(`^tests\[.*\]\s*=\s*responseCode\.code\s*===?\s*(\d+)$`),
+	}
+	postmanJSONAlias = regexp.MustCompile(`^(?:var|let|const)\s+(\w+)\s*=\s*(?:pm\.response\.json\(\)|JSON\.parse\(responseBody\))$`)
+	postmanEquals    = regexp.MustCompile(`^pm\.expect\((` + jsChain + `)\)\.to\.(?:eql|equal|deep\.equal|be\.equal)\((.+)\)$`)
+	postmanNotEmpty  = regexp.MustCompile(`^pm\.expect\((` + jsChain + `)\)\.to\.(?:exist|not\.be\.empty|be\.ok|not\.be\.undefined)$`)
+	postmanSet       = regexp.MustCompile(`^pm\.(?:environment|collectionVariables|globals|variables)\.set\(\s*["']([^"']+)["']\s*,\s*(` + jsChain + `)\s*\)$`)
+	postmanTest      = regexp.MustCompile(`^pm\.test\(\s*(?:"[^"]*"|'[^']*')\s*,\s*(?:function\s*\(\)|\(\)\s*=>)\s*\{`)
+	jsAccessor       = regexp.MustCompile(`\.(\w+)|\[(\d+)\]|\["([^"]+)"\]|\['([^']+)'\]`)
+)
+
+// postmanDynamicVariables maps Postman's built-in variables to expressions.
+var postmanDynamicVariables = map[string]string{
+	"$guid":         "uuid()",
+	"$randomUUID":   "uuid()",
+	"$timestamp":    "format(now(), 'unix')",
+	"$isoTimestamp": "format(now(), 'RFC3339')",
+}
+
+type postmanCollection struct {
+	Info struct {
+		Name string `json:"name"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+	Auth     *postmanAuth      `json:"auth"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item"`
+	Request *postmanRequest `json:"request"`
+	Event   []postmanEvent  `json:"event"`
+	Auth    *postmanAuth    `json:"auth"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanKeyValue `json:"header"`
+	URL    postmanURL        `json:"url"`
+	Body   *postmanBody      `json:"body"`
+	Auth   *postmanAuth      `json:"auth"`
+}
+
+// postmanURL is either a plain string or an object with the raw URL.
+type postmanURL struct {
+	Raw string `json:"raw"`
+}
+
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &u.Raw); err == nil {
+		return nil
+	}
+	type object postmanURL
+	return json.Unmarshal(data, (*object)(u))
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw"`
+	URLEncoded []postmanKeyValue `json:"urlencoded"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanKeyValue `json:"bearer"`
+	Basic  []postmanKeyValue `json:"basic"`
+	APIKey []postmanKeyValue `json:"apikey"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec []string `json:"exec"`
+	} `json:"script"`
+}
+
+type postmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+func (kv postmanKeyValue) active() bool {
+	return !kv.Disabled && (kv.Enabled == nil || *kv.Enabled)
+}
+
+func (kv postmanKeyValue) value() string {
+	if kv.Value == nil {
+		return ""
+	}
+	return formatValue(kv.Value)
+}
+
+// ImportPostman converts a Postman v2 collection into feature files: one per
+// top-level folder, plus one for requests outside folders. Collection
+// variables and those of the optional environment file are stored in each
+// feature's background. Simple pm.test status and JSON assertions, and
+// variables set from the response, become rbdd steps.
+func ImportPostman(collectionPath, environmentPath string) (*ImportResult, error) {
+	data, err := os.ReadFile(collectionPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection: %w", err)
+	}
+
+	var collection postmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("invalid Postman collection %s: %w", collectionPath, err)
+	}
+
+	variables := collection.Variable
+	if environmentPath != "" {
+		data, err := os.ReadFile(environmentPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read environment: %w", err)
+		}
+		var environment struct {
+			Values []postmanKeyValue `json:"values"`
+		}
+		if err := json.Unmarshal(data, &environment); err != nil {
+			return nil, fmt.Errorf("invalid Postman environment %s: %w", environmentPath, err)
+		}
+		variables = append(variables, environment.Values...)
+	}
+
+	imp := &postmanImporter{
+		result:    &ImportResult{Features: map[string]string{}},
+		variables: map[string]string{},
+		baseURLs:  map[string]bool{},
+	}
+	for _, v := range variables {
+		if !v.active() {
+			continue
+		}
+		if _, ok := imp.variables[v.Key]; !ok {
+			imp.order = append(imp.order, v.Key)
+		}
+		imp.variables[v.Key] = v.value()
+	}
+
+	name := collection.Info.Name
+	if name == "" {
+		name = "Postman collection"
+	}
+
+	var loose []postmanItem
+	for _, item := range collection.Item {
+		if item.Request == nil {
+			imp.feature(item.Name, item.Item, inheritAuth(collection.Auth, item.Auth))
+		} else {
+			loose = append(loose, item)
+		}
+	}
+	if len(loose) > 0 {
+		imp.feature(name, loose, collection.Auth)
+	}
+
+	for _, base := range slices.Sorted(maps.Keys(imp.baseURLs)) {
+		imp.result.note("requests use %s as the base URL; set it as base_url in rbdd.yaml or API_BASE_URL", base)
+	}
+
+	return imp.result, nil
+}
+
+type postmanImporter struct {
+	result    *ImportResult
+	variables map[string]string
+	// order is the order variables were declared in, collection first.
+	order    []string
+	baseURLs map[string]bool
+}
+
+func (imp *postmanImporter) feature(name string, items []postmanItem, auth *postmanAuth) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Feature: %s\n  Imported from Postman.\n", name)
+
+	if len(imp.variables) > 0 {
+		sb.WriteString("\n  Background:\n")
+		keyword := "Given"
+		for _, key := range imp.variableOrder() {
+			value := imp.convert(imp.variables[key], name+" variables")
+			if strings.Contains(value, `"`) || strings.Contains(key, `"`) {
+				imp.result.note("%s: variable %s contains a double quote and was not imported", name, key)
+				continue
+			}
+			fmt.Fprintf(&sb, "    %s I store the string \"%s\" as \"%s\"\n", keyword, value, key)
+			keyword = "And"
+		}
+	}
+
+	imp.items(&sb, "", items, auth)
+	imp.result.add(name, sb.String())
+}
+
+// variableOrder returns the variables in declaration order, moving each
+// after the variables its value references.
+func (imp *postmanImporter) variableOrder() []string {
+	var order []string
+	done := map[string]bool{}
+	var visit func(key string)
+	visit = func(key string) {
+		if done[key] {
+			return
+		}
+		done[key] = true
+		for _, match := range postmanVariable.FindAllStringSubmatch(imp.variables[key], -1) {
+			if _, ok := imp.variables[match[1]]; ok {
+				visit(match[1])
+			}
+		}
+		order = append(order, key)
+	}
+	for _, key := range imp.order {
+		visit(key)
+	}
+	return order
+}
+
+func (imp *postmanImporter) items(sb *strings.Builder, prefix string, items []postmanItem, auth *postmanAuth) {
+	for _, item := range items {
+		itemAuth := inheritAuth(auth, item.Auth)
+		if item.Request == nil {
+			imp.items(sb, prefix+item.Name+" / ", item.Item, itemAuth)
+			continue
+		}
+		sb.WriteString("\n")
+		imp.scenario(sb, prefix+item.Name, item, inheritAuth(itemAuth, item.Request.Auth))
+	}
+}
+
+func (imp *postmanImporter) scenario(sb *strings.Builder, name string, item postmanItem, auth *postmanAuth) {
+	req := item.Request
+	w := newScenarioWriter(sb, name)
+
+	for _, header := range imp.authHeaders(name, auth) {
+		w.step("Given", `I set header "%s" to "%s"`, header[0], header[1])
+	}
+	for _, header := range req.Header {
+		if !header.active() {
+			continue
+		}
+		value := imp.convert(header.value(), name)
+		if strings.Contains(value, `"`) || strings.Contains(header.Key, `"`) {
+			imp.result.note("%s: header %s contains a double quote and was not imported", name, header.Key)
+			continue
+		}
+		w.step("Given", `I set header "%s" to "%s"`, header.Key, value)
+	}
+
+	for _, event := range item.Event {
+		if event.Listen == "prerequest" && strings.TrimSpace(strings.Join(event.Script.Exec, "\n")) != "" {
+			imp.result.note("%s: pre-request script not translated", name)
+		}
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	endpoint := imp.endpoint(req.URL.Raw)
+
+	if payload := imp.payload(name, req.Body); payload != "" {
+		w.step("When", `I send a "%s" request to "%s" with payload:`, method, endpoint)
+		w.docString(payload)
+	} else {
+		w.step("When", `I send a "%s" request to "%s"`, method, endpoint)
+	}
+
+	for _, event := range item.Event {
+		if event.Listen == "test" {
+			for _, step := range imp.testSteps(name, event.Script.Exec) {
+				w.step("Then", "%s", step)
+			}
+		}
+	}
+}
+
+// endpoint converts a request URL, removing a leading {{variable}} or
+// scheme and host, which rbdd takes from its base URL.
+func (imp *postmanImporter) endpoint(raw string) string {
+	if match := postmanVariable.FindStringSubmatchIndex(raw); match != nil && match[0] == 0 {
+		name := raw[match[2]:match[3]]
+		rest := raw[match[1]:]
+		if value, ok := imp.variables[name]; ok && strings.Contains(value, "://") && (rest == "" || strings.HasPrefix(rest, "/") || strings.HasPrefix(rest, "?")) {
+			imp.baseURLs[value+" ({{"+name+"}})"] = true
+			raw = rest
+		}
+	} else if base, path := splitBaseURL(raw); base != "" {
+		imp.baseURLs[base] = true
+		raw = path
+	}
+
+	if raw == "" || raw[0] == '?' {
+		raw = "/" + raw
+	}
+	return imp.convert(raw, "")
+}
+
+// convert replaces {{variables}} with ${placeholders}.
+func (imp *postmanImporter) convert(s, context string) string {
+	return postmanVariable.ReplaceAllStringFunc(s, func(match string) string {
+		name := postmanVariable.FindStringSubmatch(match)[1]
+		if strings.HasPrefix(name, "$") {
+			expr, ok := postmanDynamicVariables[name]
+			if !ok {
+				imp.result.note("%s: dynamic variable {{%s}} not translated", context, name)
+				return match
+			}
+			return "${" + expr + "}"
+		}
+		return "${" + name + "}"
+	})
+}
+
+func (imp *postmanImporter) payload(name string, body *postmanBody) string {
+	if body == nil {
+		return ""
+	}
+
+	switch body.Mode {
+	case "", "none":
+		return ""
+	case "raw":
+		return imp.convert(body.Raw, name)
+	case "urlencoded":
+		imp.result.note("%s: urlencoded body sent as raw text; set the Content-Type header to application/x-www-form-urlencoded", name)
+		var pairs []string
+		for _, kv := range body.URLEncoded {
+			if kv.active() {
+				pairs = append(pairs, kv.Key+"="+kv.value())
+			}
+		}
+		return imp.convert(strings.Join(pairs, "&"), name)
+	default:
+		imp.result.note("%s: %s body not translated", name, body.Mode)
+		return ""
+	}
+}
+
+func (imp *postmanImporter) authHeaders(name string, auth *postmanAuth) [][2]string {
+	if auth == nil {
+		return nil
+	}
+
+	param := func(params []postmanKeyValue, key string) string {
+		for _, p := range params {
+			if p.Key == key {
+				return imp.convert(p.value(), name)
+			}
+		}
+		return ""
+	}
+	literal := func(s string) string {
+		if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") && strings.Count(s, "${") == 1 {
+			return s[2 : len(s)-1]
+		}
+		return "'" + s + "'"
+	}
+
+	switch auth.Type {
+	case "noauth", "":
+		return nil
+	case "bearer":
+		return [][2]string{{"Authorization", "Bearer " + param(auth.Bearer, "token")}}
+	case "basic":
+		if strings.Contains(param(auth.Basic, "username")+param(auth.Basic, "password"), "'") {
+			imp.result.note("%s: basic credentials containing a single quote not translated", name)
+			return nil
+		}
+		user, pass := literal(param(auth.Basic, "username")), literal(param(auth.Basic, "password"))
+		return [][2]string{{"Authorization", "Basic ${base64(" + user + " + ':' + " + pass + ")}"}}
+	case "apikey":
+		if param(auth.APIKey, "in") == "query" {
+			imp.result.note("%s: API key in the query string not translated", name)
+			return nil
+		}
+		return [][2]string{{param(auth.APIKey, "key"), param(auth.APIKey, "value")}}
+	default:
+		imp.result.note("%s: %s authentication not translated", name, auth.Type)
+		return nil
+	}
+}
+
+// testSteps translates the statements of a test script into steps, noting
+// the ones it cannot translate.
+func (imp *postmanImporter) testSteps(name string, exec []string) []string {
+	var steps []string
+	aliases := map[string]bool{"pm.response.json()": true}
+
+	for _, stmt := range scriptStatements(exec) {
+		if match := postmanJSONAlias.FindStringSubmatch(stmt); match != nil {
+			aliases[match[1]] = true
+			continue
+		}
+
+		step := ""
+		for _, pattern := range postmanStatus {
+			if match := pattern.FindStringSubmatch(stmt); match != nil {
+				step = "the response status should be " + match[1]
+			}
+		}
+		if match := postmanEquals.FindStringSubmatch(stmt); match != nil {
+			if path, ok := jsonPath(match[1], aliases); ok {
+				if value, ok := jsValue(match[2]); ok {
+					step = fmt.Sprintf(`the response property "%s" should be %s`, path, value)
+				}
+			}
+		}
+		if match := postmanNotEmpty.FindStringSubmatch(stmt); match != nil {
+			if path, ok := jsonPath(match[1], aliases); ok {
+				step = fmt.Sprintf(`the response property "%s" should not be empty`, path)
+			}
+		}
+		if match := postmanSet.FindStringSubmatch(stmt); match != nil {
+			if path, ok := jsonPath(match[2], aliases); ok {
+				step = fmt.Sprintf(`I store the response property "%s" as "%s"`, path, match[1])
+			}
+		}
+
+		if step == "" {
+			imp.result.note("%s: test statement not translated: %s", name, stmt)
+			continue
+		}
+		steps = append(steps, step)
+	}
+
+	return steps
+}
+
+// scriptStatements splits a script into statements, unwrapping pm.test
+// callbacks and dropping comments and closing braces.
+func scriptStatements(exec []string) []string {
+	var statements []string
+	for _, line := range exec {
+		if i := strings.Index(line, "//"); i >= 0 && !strings.Contains(line[:i], "://") {
+			line = line[:i]
+		}
+		for _, stmt := range strings.Split(line, ";") {
+			stmt = strings.TrimSpace(stmt)
+			if loc := postmanTest.FindStringIndex(stmt); loc != nil {
+				stmt = strings.TrimSpace(stmt[loc[1]:])
+			}
+			stmt = strings.TrimSpace(strings.TrimLeft(stmt, "}) "))
+			if stmt != "" {
+				statements = append(statements, stmt)
+			}
+		}
+	}
+	return statements
+}
+
+// jsonPath converts a JavaScript accessor chain on a parsed response, such
+// as jsonData.items[0].id, into a gjson path.
+func jsonPath(expr string, aliases map[string]bool) (string, bool) {
+	root := expr
+	if i := strings.IndexAny(expr, ".["); i >= 0 {
+		root = expr[:i]
+	}
+	if strings.HasPrefix(expr, "pm.response.json()") {
+		root = "pm.response.json()"
+	}
+	if !aliases[root] {
+		return "", false
+	}
+
+	var parts []string
+	for _, match := range jsAccessor.FindAllStringSubmatch(expr[len(root):], -1) {
+		for _, part := range match[1:] {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+	if len(parts) == 0 || strings.Contains(strings.Join(parts, ""), `"`) {
+		return "", false
+	}
+	return strings.Join(parts, "."), true
+}
+
+// jsValue converts a JavaScript literal into the expected value of a
+// response property step.
+func jsValue(literal string) (string, bool) {
+	literal = strings.TrimSpace(literal)
+	switch {
+	case literal == "true" || literal == "false":
+		return literal, true
+	case len(literal) >= 2 && (literal[0] == '"' || literal[0] == '\'') && literal[len(literal)-1] == literal[0]:
+		s := literal[1 : len(literal)-1]
+		return `"` + s + `"`, !strings.Contains(s, `"`)
+	}
+	if _, err := strconv.ParseFloat(literal, 64); err == nil {
+		return literal, true
+	}
+	return "", false
+}
+
+func inheritAuth(parent, child *postmanAuth) *postmanAuth {
+	if child != nil {
+		return child
+	}
+	return parent
+}
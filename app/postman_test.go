@@ -0,0 +1,151 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const shopCollection = `{
+  "info": {"name": "Shop API"},
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}"}]},
+  "variable": [{"key": "baseUrl", "value": "https://api.shop.test/v1"}],
+  "item": [
+    {"name": "Users", "item": [
+      {"name": "Create user",
+       "event": [{"listen": "test", "script": {"exec": [
+         "pm.test(\"Status code is 201\", function () {",
+         "    pm.response.to.have.status(201);",
+         "});",
+         "var jsonData = pm.response.json();",
+         "pm.test(\"name\", function () { pm.expect(jsonData.data.name).to.eql('Jane'); });",
+         "pm.expect(pm.response.json().data.tags[0]).to.exist;",
+         "pm.collectionVariables.set(\"user_id\", jsonData.data.id);",
+         "pm.expect(pm.response.responseTime).to.be.below(200);"
+       ]}}],
+       "request": {"method": "POST",
+         "header": [{"key": "Accept", "value": "application/json"}, {"key": "X-Old", "value": "1", "disabled": true}],
+         "body": {"mode": "raw", "raw": "{\"name\": \"Jane\", \"ref\": \"{{$guid}}\"}"},
+         "url": {"raw": "{{baseUrl}}/users", "host": ["{{baseUrl}}"], "path": ["users"]}}},
+      {"name": "Admin", "item": [
+        {"name": "Get user", "auth": {"type": "basic", "basic": [{"key": "username", "value": "admin"}, {"key": "password", "value": "{{admin_pass}}"}]},
+         "request": {"method": "GET", "url": "{{baseUrl}}/users/{{user_id}}"}}
+      ]}
+    ]},
+    {"name": "Health", "request": {"method": "GET", "url": "https://api.shop.test/health"},
+     "event": [{"listen": "prerequest", "script": {"exec": ["console.log(1)"]}}]}
+  ]
+}`
+
+func TestImportPostman(t *testing.T) {
+	dir := t.TempDir()
+	collection := filepath.Join(dir, "collection.json")
+	environment := filepath.Join(dir, "env.json")
+	if err := os.WriteFile(collection, []byte(shopCollection), 0o644); err != nil {
+		t.Fatalf("Failed to write collection: %v", err)
+	}
+	env := `{"values": [{"key": "token", "value": "abc", "enabled": true}, {"key": "admin_pass", "value": "s3cret", "enabled": true}, {"key": "off", "value": "x", "enabled": false}]}`
+	if err := os.WriteFile(environment, []byte(env), 0o644); err != nil {
+		t.Fatalf("Failed to write environment: %v", err)
+	}
+
+	result, err := ImportPostman(collection, environment)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Features) != 2 {
+		t.Fatalf("Expected 2 features, got %v", result.Features)
+	}
+
+	users := result.Features["users.feature"]
+	for _, expected := range []string{
+		"Feature: Users\n",
+		"  Background:\n    Given I store the string \"https://api.shop.test/v1\" as \"baseUrl\"\n    And I store the string \"abc\" as \"token\"\n    And I store the string \"s3cret\" as \"admin_pass\"\n",
+		`    Given I set header "Authorization" to "Bearer ${token}"` + "\n" + `    And I set header "Accept" to "application/json"` + "\n",
+		`    When I send a "POST" request to "/users" with payload:`,
+		`{"name": "Jane", "ref": "${uuid()}"}`,
+		"    Then the response status should be 201\n" +
+			"    And the response property \"data.name\" should be \"Jane\"\n" +
+			"    And the response property \"data.tags.0\" should not be empty\n" +
+			"    And I store the response property \"data.id\" as \"user_id\"\n",
+		"  Scenario: Admin / Get user\n",
+		`    When I send a "GET" request to "/users/${user_id}"`,
+	} {
+		if !strings.Contains(users, expected) {
+			t.Errorf("Expected users.feature to contain %q, got:\n%s", expected, users)
+		}
+	}
+	if strings.Contains(users, "X-Old") || strings.Contains(users, `"off"`) {
+		t.Errorf("Expected disabled headers and variables to be skipped, got:\n%s", users)
+	}
+
+	if health := result.Features["shop_api.feature"]; !strings.Contains(health, `When I send a "GET" request to "/health"`) {
+		t.Errorf("Expected requests outside folders in shop_api.feature, got:\n%s", health)
+	}
+
+	notes := strings.Join(result.Notes, "\n")
+	for _, expected := range []string{
+		"Create user: test statement not translated: pm.expect(pm.response.responseTime).to.be.below(200)",
+		"Health: pre-request script not translated",
+		"https://api.shop.test/v1 ({{baseUrl}}) as the base URL",
+	} {
+		if !strings.Contains(notes, expected) {
+			t.Errorf("Expected notes to contain %q, got:\n%s", expected, notes)
+		}
+	}
+
+	apiTest := NewAPITest("")
+	apiTest.store["admin_pass"] = "s3cret"
+	header, err := apiTest.replaceVars("Basic ${base64('admin' + ':' + admin_pass)}")
+	if err != nil || !strings.Contains(users, "${base64('admin' + ':' + admin_pass)}") {
+		t.Fatalf("Expected a basic auth header expression, got %q, %v", users, err)
+	}
+	if header != "Basic YWRtaW46czNjcmV0" {
+		t.Errorf("Expected basic auth header to be Basic YWRtaW46czNjcmV0, got %s", header)
+	}
+}
+
+func TestImportPostmanVariableOrder(t *testing.T) {
+	dir := t.TempDir()
+	collection := filepath.Join(dir, "collection.json")
+	data := `{
+  "info": {"name": "Agents"},
+  "variable": [
+    {"key": "api_url", "value": "{{host}}/v1"},
+    {"key": "host", "value": "https://agents.test"},
+    {"key": "agent_id", "value": "007"},
+    {"key": "active", "value": "true"}
+  ],
+  "item": [{"name": "Get agent", "request": {"method": "GET", "url": "{{api_url}}/agents/{{agent_id}}"}}]
+}`
+	if err := os.WriteFile(collection, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write collection: %v", err)
+	}
+
+	result, err := ImportPostman(collection, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	feature := result.Features["agents.feature"]
+	expected := "  Background:\n" +
+		"    Given I store the string \"https://agents.test\" as \"host\"\n" +
+		"    And I store the string \"${host}/v1\" as \"api_url\"\n" +
+		"    And I store the string \"007\" as \"agent_id\"\n" +
+		"    And I store the string \"true\" as \"active\"\n"
+	if !strings.Contains(feature, expected) {
+		t.Fatalf("Expected variables in dependency order, got:\n%s", feature)
+	}
+
+	apiTest := NewAPITest("")
+	for _, step := range [][2]string{{"https://agents.test", "host"}, {"${host}/v1", "api_url"}, {"007", "agent_id"}, {"true", "active"}} {
+		if err := apiTest.iStoreTheStringAs(step[0], step[1]); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if apiTest.store["api_url"] != "https://agents.test/v1" || apiTest.store["agent_id"] != "007" || apiTest.store["active"] != "true" {
+		t.Errorf("Expected literal strings, got %v", apiTest.store)
+	}
+}
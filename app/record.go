@@ -0,0 +1,434 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// idKeyPattern matches response properties holding identifiers, whose
+	// values are worth looking for in later URLs.
+	idKeyPattern = regexp.MustCompile(`(?i)^(id|uuid|slug|key)$|[_-](id|uuid|slug|key)$|[a-z](Id|ID|Uuid|Slug|Key)$`)
+	// bareIDKeyPattern matches identifier properties named too generically
+	// to be used as variable names on their own.
+	bareIDKeyPattern = regexp.MustCompile(`(?i)^(id|uuid|slug|key)$`)
+	camelBoundary    = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// recordedExchange is a request proxied by a Recorder and its response.
+type recordedExchange struct {
+	method      string
+	uri         string
+	headers     http.Header
+	body        string
+	status      int
+	contentType string
+	response    string
+}
+
+// recordable reports whether an exchange is an API call that can be written
+// as steps, rather than a page or static asset.
+func (ex recordedExchange) recordable() bool {
+	if ex.method == "GET" && !strings.Contains(ex.contentType, "json") {
+		return false
+	}
+	return !strings.Contains(ex.uri, `"`)
+}
+
+// Recorder is a reverse proxy that records the requests it forwards so they
+// can be written out as a feature.
+type Recorder struct {
+	proxy     *httputil.ReverseProxy
+	log       io.Writer
+	mu        sync.Mutex
+	exchanges []recordedExchange
+}
+
+// NewRecorder returns a Recorder forwarding to upstream, logging each
+// request to log if it is not nil.
+func NewRecorder(upstream string, log io.Writer) (*Recorder, error) {
+	target, err := url.Parse(upstream)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL %q", upstream)
+	}
+
+	return &Recorder{
+		proxy: &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.SetURL(target)
+				// Let the transport negotiate compression so recorded
+				// bodies are plain text.
+				pr.Out.Header.Del("Accept-Encoding")
+			},
+		},
+		log: log,
+	}, nil
+}
+
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	headers := req.Header.Clone()
+
+	capture := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+	r.proxy.ServeHTTP(capture, req)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, recordedExchange{
+		method:      req.Method,
+		uri:         req.URL.RequestURI(),
+		headers:     headers,
+		body:        string(body),
+		status:      capture.status,
+		contentType: w.Header().Get("Content-Type"),
+		response:    capture.body.String(),
+	})
+
+	if r.log != nil {
+		fmt.Fprintf(r.log, "%s %s -> %d\n", req.Method, req.URL.RequestURI(), capture.status)
+	}
+}
+
+// Len returns the number of requests recorded so far.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.exchanges)
+}
+
+// Feature renders the recorded requests as a feature with a single
+// scenario, so identifiers returned by one request can flow into the next.
+func (r *Recorder) Feature(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return recordFeature(name, r.exchanges)
+}
+
+// captureWriter copies the status and body written to a ResponseWriter.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	c.body.Write(p)
+	return c.ResponseWriter.Write(p)
+}
+
+// capturedID is an identifier in a recorded response that a later request
+// uses, stored in variable.
+type capturedID struct {
+	exchange int
+	path     string
+	value    string
+	variable string
+}
+
+// recordRenderer holds the state of rendering a recording: the identifiers
+// seen in responses so far and those that later requests reuse.
+type recordRenderer struct {
+	exchanges []recordedExchange
+	seen      map[string]*capturedID
+	captured  map[int][]*capturedID
+	variables map[string]bool
+}
+
+func recordFeature(name string, exchanges []recordedExchange) string {
+	r := &recordRenderer{
+		exchanges: exchanges,
+		seen:      map[string]*capturedID{},
+		captured:  map[int][]*capturedID{},
+		variables: map[string]bool{},
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Feature: %s\n  Recorded on %s.\n\n", name, time.Now().Format(time.DateOnly))
+	w := newScenarioWriter(&sb, "Recorded session")
+
+	// Resolve every URL first, so a response knows which of its
+	// identifiers to store before its steps are written.
+	uris := make([]string, len(exchanges))
+	for i, ex := range exchanges {
+		if ex.recordable() {
+			uris[i] = r.substituteURI(ex.uri)
+			r.collectIDs(i)
+		}
+	}
+
+	headers := http.Header{"Content-Type": {"application/json"}}
+	for i, ex := range exchanges {
+		if !ex.recordable() {
+			continue
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(ex.headers)) {
+			lower := strings.ToLower(key)
+			value := ex.headers.Get(key)
+			if harSkippedHeaders[lower] || strings.HasPrefix(lower, "sec-") || strings.Contains(value, `"`) ||
+				headers.Get(key) == value || value == "*/*" {
+				continue
+			}
+			headers.Set(key, value)
+			w.step("Given", `I set header "%s" to "%s"`, key, value)
+		}
+
+		if ex.body != "" {
+			w.step("When", `I send a "%s" request to "%s" with payload:`, ex.method, uris[i])
+			w.docString(ex.body)
+		} else {
+			w.step("When", `I send a "%s" request to "%s"`, ex.method, uris[i])
+		}
+		w.step("Then", "the response status should be %d", ex.status)
+
+		if expected := r.expectedJSON(i); expected != "" {
+			w.step("Then", "the response should contain JSON:")
+			w.docString(expected)
+		}
+		for _, id := range r.captured[i] {
+			w.step("Then", `I store the response property "%s" as "%s"`, id.path, id.variable)
+		}
+	}
+
+	return sb.String()
+}
+
+// collectIDs remembers the identifiers in a response, replacing those of
+// earlier responses with the same value unless they are already stored.
+func (r *recordRenderer) collectIDs(i int) {
+	var body any
+	if json.Unmarshal([]byte(r.exchanges[i].response), &body) != nil {
+		return
+	}
+
+	walkJSON(body, "", func(path string, value any) {
+		key := path[strings.LastIndex(path, ".")+1:]
+		if !idKeyPattern.MatchString(key) {
+			return
+		}
+		if s, ok := idString(value); ok && (r.seen[s] == nil || r.seen[s].variable == "") {
+			r.seen[s] = &capturedID{exchange: i, path: path, value: s}
+		}
+	})
+}
+
+// substituteURI replaces the path segments and query values of uri that
+// hold identifiers from earlier responses with ${...} references.
+func (r *recordRenderer) substituteURI(uri string) string {
+	path, query, hasQuery := strings.Cut(uri, "?")
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = r.reference(segment)
+	}
+	uri = strings.Join(segments, "/")
+
+	if hasQuery {
+		params := strings.Split(query, "&")
+		for i, param := range params {
+			if key, value, ok := strings.Cut(param, "="); ok {
+				params[i] = key + "=" + r.reference(value)
+			}
+		}
+		uri += "?" + strings.Join(params, "&")
+	}
+
+	return uri
+}
+
+// reference returns a ${...} reference for an escaped URL part holding a
+// known identifier, capturing it on first use, or the part unchanged.
+func (r *recordRenderer) reference(part string) string {
+	value, err := url.PathUnescape(part)
+	if err != nil || value == "" {
+		return part
+	}
+	id, ok := r.seen[value]
+	if !ok {
+		return part
+	}
+
+	if id.variable == "" {
+		id.variable = r.variableName(id)
+		r.captured[id.exchange] = append(r.captured[id.exchange], id)
+	}
+	return "${" + id.variable + "}"
+}
+
+// variableName names a captured identifier after its property, qualifying
+// a bare key such as "id" with its parent property or the resource that returned it,
+// e.g. "data.id" from "POST /users" becomes "user_id".
+func (r *recordRenderer) variableName(id *capturedID) string {
+	keys := strings.Split(id.path, ".")
+	name := keys[len(keys)-1]
+
+	if bareIDKeyPattern.MatchString(name) {
+		qualifier := ""
+		for j := len(keys) - 2; j >= 0 && qualifier == ""; j-- {
+			if _, err := strconv.Atoi(keys[j]); err != nil && keys[j] != "data" && keys[j] != "result" {
+				qualifier = keys[j]
+			}
+		}
+		if qualifier == "" {
+			qualifier = resourceName(r.exchanges[id.exchange].uri)
+		}
+		if qualifier != "" {
+			name = qualifier + "_" + name
+		}
+	}
+
+	name = strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(camelBoundary.ReplaceAllString(name, "${1}_${2}")), "_"), "_")
+	if name == "" {
+		name = "id"
+	}
+
+	unique := name
+	for n := 2; r.variables[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", name, n)
+	}
+	r.variables[unique] = true
+	return unique
+}
+
+// expectedJSON renders the stable parts of a JSON object response for a
+// contain JSON assertion. Identifiers and timestamps change between runs,
+// so they are left out unless they were stored from an earlier response,
+// and arrays are left out as they must match exactly.
+func (r *recordRenderer) expectedJSON(i int) string {
+	var body map[string]any
+	if json.Unmarshal([]byte(r.exchanges[i].response), &body) != nil {
+		return ""
+	}
+
+	var placeholders []string
+	var stable func(obj map[string]any, path string) map[string]any
+	stable = func(obj map[string]any, path string) map[string]any {
+		result := map[string]any{}
+		for key, value := range obj {
+			switch val := value.(type) {
+			case map[string]any:
+				if nested := stable(val, schemaPath(path, key)); len(nested) > 0 {
+					result[key] = nested
+				}
+				continue
+			case []any:
+				continue
+			}
+
+			if s, ok := idString(value); ok {
+				if id := r.seen[s]; id != nil && id.variable != "" && id.exchange < i {
+					placeholder := fmt.Sprintf("\x00%d\x00", len(placeholders))
+					if _, isString := value.(string); isString {
+						placeholders = append(placeholders, `"${`+id.variable+`}"`)
+					} else {
+						placeholders = append(placeholders, "${"+id.variable+"}")
+					}
+					result[key] = placeholder
+					continue
+				}
+			}
+			if idKeyPattern.MatchString(key) {
+				continue
+			}
+			if s, ok := value.(string); ok {
+				if _, err := time.Parse(time.RFC3339, s); err == nil {
+					continue
+				}
+			}
+			result[key] = value
+		}
+		return result
+	}
+
+	expected := stable(body, "")
+	if len(expected) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(expected); err != nil {
+		return ""
+	}
+
+	rendered := strings.TrimSuffix(buf.String(), "\n")
+	for n, placeholder := range placeholders {
+		rendered = strings.Replace(rendered, fmt.Sprintf(`"\u0000%d\u0000"`, n), placeholder, 1)
+	}
+	return rendered
+}
+
+// walkJSON calls fn with the gjson path of every scalar in value.
+func walkJSON(value any, path string, fn func(path string, value any)) {
+	switch val := value.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(val)) {
+			if !strings.ContainsAny(key, ".*?#|@\\") {
+				walkJSON(val[key], schemaPath(path, key), fn)
+			}
+		}
+	case []any:
+		for i, item := range val {
+			walkJSON(item, schemaPath(path, strconv.Itoa(i)), fn)
+		}
+	default:
+		if path != "" {
+			fn(path, value)
+		}
+	}
+}
+
+// idString formats strings and whole numbers as they would appear in a URL.
+func idString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, v != ""
+	case float64:
+		if v == float64(int64(v)) {
+			return strconv.FormatInt(int64(v), 10), true
+		}
+	}
+	return "", false
+}
+
+// resourceName returns the singular of the last path segment of uri that is
+// not an identifier, e.g. "user" for "/users" and "/users/42".
+func resourceName(uri string) string {
+	path, _, _ := strings.Cut(uri, "?")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for j := len(segments) - 1; j >= 0; j-- {
+		segment := segments[j]
+		if segment == "" || strings.ContainsAny(segment, "0123456789") {
+			continue
+		}
+		if strings.HasSuffix(segment, "ies") {
+			return strings.TrimSuffix(segment, "ies") + "y"
+		}
+		return strings.TrimSuffix(segment, "s")
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "POST":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"data": {"id": 42, "name": "Jo", "created_at": "2025-01-01T00:00:00Z"}}`)
+		case r.URL.Path == "/users/42":
+			fmt.Fprint(w, `{"id": 42, "name": "Jo", "org": {"slug": "acme"}, "tags": ["a"]}`)
+		default:
+			fmt.Fprint(w, `{"name": "Acme"}`)
+		}
+	}))
+	defer upstream.Close()
+
+	var log strings.Builder
+	recorder, err := NewRecorder(upstream.URL, &log)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	proxy := httptest.NewServer(recorder)
+	defer proxy.Close()
+
+	apiTest := NewAPITest(proxy.URL)
+	apiTest.headers["X-Tenant"] = "t1"
+	for _, req := range []struct{ method, endpoint, payload string }{
+		{"POST", "/users", `{"name":"Jo"}`},
+		{"GET", "/users/42", ""},
+		{"GET", "/orgs/acme?owner=42", ""},
+	} {
+		if err := apiTest.sendRequest(req.method, req.endpoint, req.payload); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if recorder.Len() != 3 {
+		t.Fatalf("Expected 3 recorded requests, got %d", recorder.Len())
+	}
+	if !strings.Contains(log.String(), "GET /users/42 -> 200\n") {
+		t.Errorf("Expected requests to be logged, got %s", log.String())
+	}
+
+	feature := recorder.Feature("users")
+	expected := `  Scenario: Recorded session
+    Given I set header "X-Tenant" to "t1"
+    When I send a "POST" request to "/users" with payload:
+      """
+      {"name":"Jo"}
+      """
+    Then the response status should be 201
+    And the response should contain JSON:
+      """
+      {
+        "data": {
+          "name": "Jo"
+        }
+      }
+      """
+    And I store the response property "data.id" as "user_id"
+    When I send a "GET" request to "/users/${user_id}"
+    Then the response status should be 200
+    And the response should contain JSON:
+      """
+      {
+        "id": ${user_id},
+        "name": "Jo"
+      }
+      """
+    And I store the response property "org.slug" as "org_slug"
+    When I send a "GET" request to "/orgs/${org_slug}?owner=${user_id}"
+    Then the response status should be 200
+`
+	if !strings.HasPrefix(feature, "Feature: users\n") || !strings.Contains(feature, expected) {
+		t.Errorf("Expected feature to contain:\n%s\ngot:\n%s", expected, feature)
+	}
+}
+
+func TestNewRecorderInvalidUpstream(t *testing.T) {
+	if _, err := NewRecorder("localhost:8080", nil); err == nil {
+		t.Errorf("Expected an error for an upstream without a scheme")
+	}
+}
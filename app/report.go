@@ -0,0 +1,130 @@
+package app
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/cucumber/godog/formatters"
+	messages "github.com/cucumber/messages/go/v21"
+)
+
+// Formatter returns a formatter that prints nothing and moves the locations
+// of features served expanded to the lines of their original files. Listed
+// before the other formats, as in "rbdd-lines,pretty", it runs before them
+// for each feature, so they report the original lines.
+func (f *FeatureFS) Formatter() formatters.FormatterFunc {
+	return func(string, io.Writer) formatters.Formatter {
+		return &sourceFormatter{lines: f.lines}
+	}
+}
+
+type sourceFormatter struct {
+	lines map[string][]int
+}
+
+// Feature is called before any scenario of the feature is formatted, and
+// every later report reads the same document.
+func (f *sourceFormatter) Feature(doc *messages.GherkinDocument, uri string, _ []byte) {
+	file, _ := featurePathLine(uri)
+	if lines, ok := f.lines[filepath.Clean(file)]; ok {
+		relocate(doc, lines)
+	}
+}
+
+func (f *sourceFormatter) TestRunStarted()         {}
+func (f *sourceFormatter) Pickle(*messages.Pickle) {}
+func (f *sourceFormatter) Summary()                {}
+func (f *sourceFormatter) Defined(*messages.Pickle, *messages.PickleStep, *formatters.StepDefinition) {
+}
+func (f *sourceFormatter) Failed(*messages.Pickle, *messages.PickleStep, *formatters.StepDefinition, error) {
+}
+func (f *sourceFormatter) Passed(*messages.Pickle, *messages.PickleStep, *formatters.StepDefinition) {
+}
+func (f *sourceFormatter) Skipped(*messages.Pickle, *messages.PickleStep, *formatters.StepDefinition) {
+}
+func (f *sourceFormatter) Undefined(*messages.Pickle, *messages.PickleStep, *formatters.StepDefinition) {
+}
+func (f *sourceFormatter) Pending(*messages.Pickle, *messages.PickleStep, *formatters.StepDefinition) {
+}
+func (f *sourceFormatter) Ambiguous(*messages.Pickle, *messages.PickleStep, *formatters.StepDefinition, error) {
+}
+
+// relocate replaces every line in doc by lines[line].
+func relocate(doc *messages.GherkinDocument, lines []int) {
+	move := func(loc *messages.Location) {
+		if loc != nil && int(loc.Line) < len(lines) {
+			loc.Line = int64(lines[loc.Line])
+		}
+	}
+	moveTags := func(tags []*messages.Tag) {
+		for _, tag := range tags {
+			move(tag.Location)
+		}
+	}
+	moveRows := func(rows ...*messages.TableRow) {
+		for _, row := range rows {
+			if row == nil {
+				continue
+			}
+			move(row.Location)
+			for _, cell := range row.Cells {
+				move(cell.Location)
+			}
+		}
+	}
+	moveSteps := func(steps []*messages.Step) {
+		for _, step := range steps {
+			move(step.Location)
+			if step.DocString != nil {
+				move(step.DocString.Location)
+			}
+			if step.DataTable != nil {
+				move(step.DataTable.Location)
+				moveRows(step.DataTable.Rows...)
+			}
+		}
+	}
+	moveBackground := func(bg *messages.Background) {
+		move(bg.Location)
+		moveSteps(bg.Steps)
+	}
+	moveScenario := func(sc *messages.Scenario) {
+		move(sc.Location)
+		moveTags(sc.Tags)
+		moveSteps(sc.Steps)
+		for _, examples := range sc.Examples {
+			move(examples.Location)
+			moveTags(examples.Tags)
+			moveRows(examples.TableHeader)
+			moveRows(examples.TableBody...)
+		}
+	}
+
+	for _, comment := range doc.Comments {
+		move(comment.Location)
+	}
+	if doc.Feature == nil {
+		return
+	}
+	move(doc.Feature.Location)
+	moveTags(doc.Feature.Tags)
+	for _, child := range doc.Feature.Children {
+		switch {
+		case child.Background != nil:
+			moveBackground(child.Background)
+		case child.Scenario != nil:
+			moveScenario(child.Scenario)
+		case child.Rule != nil:
+			move(child.Rule.Location)
+			moveTags(child.Rule.Tags)
+			for _, ruleChild := range child.Rule.Children {
+				if ruleChild.Background != nil {
+					moveBackground(ruleChild.Background)
+				}
+				if ruleChild.Scenario != nil {
+					moveScenario(ruleChild.Scenario)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// eventStream is a Server-Sent Events subscription, with the events received
+// on it so far.
+type eventStream struct {
+	body     io.ReadCloser
+	mu       sync.Mutex
+	received []*serverEvent
+	arrived  chan struct{}
+	closed   bool
+}
+
+type serverEvent struct {
+	id       string
+	name     string
+	data     string
+	consumed bool
+}
+
+// isEventStream reports whether a response is a Server-Sent Events stream,
+// which never ends and must not be read whole.
+func isEventStream(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
+
+// read parses events until the stream ends, waking waiting steps on every
+// event and at the end.
+func (s *eventStream) read() {
+	scanner := bufio.NewScanner(s.body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event serverEvent
+	var data []string
+	var lastID string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data != nil {
+				event.id = lastID
+				if event.name == "" {
+					event.name = "message"
+				}
+				event.data = strings.Join(data, "\n")
+				s.add(event)
+			}
+			event, data = serverEvent{}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.name = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				lastID = value
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.closed = true
+	close(s.arrived)
+	s.arrived = make(chan struct{})
+	s.mu.Unlock()
+}
+
+func (s *eventStream) add(event serverEvent) {
+	s.mu.Lock()
+	s.received = append(s.received, &event)
+	close(s.arrived)
+	s.arrived = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// resetEvents closes the event stream so each scenario starts without one.
+func (a *APITest) resetEvents() {
+	if a.events != nil {
+		a.events.body.Close()
+		a.events = nil
+	}
+	a.event = nil
+}
+
+func (a *APITest) iSubscribeTo(endpoint string) error {
+	a.lastRequest = nil
+	a.resetTiming()
+
+	endpoint, err := a.replaceVars(endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", a.baseURL+endpoint, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range a.headers {
+		if strings.EqualFold(k, "Content-Type") {
+			continue
+		}
+		value, err := a.replaceVars(v)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(k, value)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	a.lastRequest = &lastRequest{method: "GET", url: req.URL.String(), headers: req.Header.Clone()}
+
+	if a.debug {
+		fmt.Printf("Subscribing to %s\n", req.URL)
+	}
+
+	a.resetEvents()
+	// The client timeout would cut the stream off, so only its transport
+	// is used.
+	client := &http.Client{Transport: a.client.Transport, Jar: a.client.Jar}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	a.response = resp
+	a.responseBody = ""
+
+	if resp.StatusCode != http.StatusOK || !isEventStream(resp) {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		a.responseBody = string(body)
+		return fmt.Errorf("expected an event stream but got status %d with Content-Type %q: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+
+	a.events = &eventStream{body: resp.Body, arrived: make(chan struct{})}
+	go a.events.read()
+
+	return nil
+}
+
+func (a *APITest) iShouldReceiveAnEventWithin(name, timeout string) error {
+	return a.iShouldReceiveAnEventWithinWithDataContaining(name, timeout, "")
+}
+
+// iShouldReceiveAnEventWithinWithDataContaining waits for an event whose
+// JSON data contains the expected subset. Each event matches one step;
+// events that do not match stay available.
+func (a *APITest) iShouldReceiveAnEventWithinWithDataContaining(name, timeout, expected string) error {
+	if a.events == nil {
+		return fmt.Errorf(`no event stream in this scenario, add: When I subscribe to "/events"`)
+	}
+
+	wait, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q: %w", timeout, err)
+	}
+	name, err = a.replaceVars(name)
+	if err != nil {
+		return err
+	}
+
+	var subset map[string]any
+	if expected != "" {
+		templated, err := a.replaceVars(expected)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(templated), &subset); err != nil {
+			return fmt.Errorf("invalid expected JSON: %w", err)
+		}
+	}
+
+	deadline := time.After(wait)
+	for {
+		a.events.mu.Lock()
+		event, mismatches := a.events.match(name, subset)
+		arrived, closed := a.events.arrived, a.events.closed
+		a.events.mu.Unlock()
+
+		if event != nil {
+			a.event = event
+			if a.debug {
+				fmt.Printf("Received event %s (id %q): %s\n", event.name, event.id, event.data)
+			}
+			return nil
+		}
+
+		// Nothing more arrives once the stream has ended.
+		if !closed {
+			select {
+			case <-arrived:
+				continue
+			case <-deadline:
+			}
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "no matching %s event received", name)
+		if closed {
+			sb.WriteString(" before the stream ended")
+		} else {
+			fmt.Fprintf(&sb, " within %s", wait)
+		}
+		for _, mismatch := range mismatches {
+			fmt.Fprintf(&sb, "\n  not matching: %s", mismatch)
+		}
+		return errors.New(sb.String())
+	}
+}
+
+// match consumes the first unconsumed event with the name whose JSON data
+// contains subset, describing why the other events of that name did not
+// match. The lock must be held.
+func (s *eventStream) match(name string, subset map[string]any) (*serverEvent, []string) {
+	var mismatches []string
+	for _, event := range s.received {
+		if event.consumed || event.name != name {
+			continue
+		}
+		if subset != nil {
+			var actual map[string]any
+			if err := json.Unmarshal([]byte(event.data), &actual); err != nil {
+				mismatches = append(mismatches, fmt.Sprintf("data is not a JSON object: %s", event.data))
+				continue
+			}
+			if err := containsSubset(actual, subset); err != nil {
+				mismatches = append(mismatches, err.Error())
+				continue
+			}
+		}
+		event.consumed = true
+		return event, nil
+	}
+	return nil, mismatches
+}
+
+func (a *APITest) iStoreTheEventIDAs(variable string) error {
+	if a.event == nil {
+		return fmt.Errorf("no event has been received in this scenario")
+	}
+	a.store[variable] = a.event.id
+
+	if a.debug {
+		fmt.Printf("Stored event id as %s: %s\n", variable, a.event.id)
+	}
+
+	return nil
+}
+
+func (a *APITest) iStoreTheEventDataAs(variable string) error {
+	if a.event == nil {
+		return fmt.Errorf("no event has been received in this scenario")
+	}
+	if gjson.Valid(a.event.data) {
+		a.store[variable] = storedValue(gjson.Parse(a.event.data))
+	} else {
+		a.store[variable] = a.event.data
+	}
+
+	if a.debug {
+		fmt.Printf("Stored event data as %s: %v\n", variable, a.store[variable])
+	}
+
+	return nil
+}
+
+func (a *APITest) iStoreTheEventPropertyAs(property, variable string) error {
+	if a.event == nil {
+		return fmt.Errorf("no event has been received in this scenario")
+	}
+
+	value := gjson.Get(a.event.data, property)
+	if !value.Exists() {
+		return fmt.Errorf("property %s not found in event data %s", property, a.event.data)
+	}
+	a.store[variable] = storedValue(value)
+
+	if a.debug {
+		fmt.Printf("Stored event property %s as %s: %v\n", property, variable, a.store[variable])
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEventServer streams a few events, then keeps the stream open until the
+// client goes away.
+func newEventServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		flusher := w.(http.Flusher)
+
+		fmt.Fprint(w, ": connected\n\n")
+		fmt.Fprint(w, "data: hello\n\n")
+		flusher.Flush()
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "event: order.created\nid: 1\ndata: {\"id\": 41, \"status\": \"draft\"}\n\n")
+		fmt.Fprintf(w, "event: order.created\nid: 2\ndata: {\"id\": 42,\ndata: \"status\": \"pending\", \"tenant\": %q}\n\n", r.Header.Get("X-Tenant"))
+		flusher.Flush()
+
+		<-r.Context().Done()
+	}))
+}
+
+func TestServerSentEvents(t *testing.T) {
+	server := newEventServer()
+	defer server.Close()
+
+	apiTest := NewAPITest(server.URL)
+	apiTest.headers = map[string]string{"Content-Type": "application/json", "X-Tenant": "acme"}
+	apiTest.store["order_id"] = 42
+	defer apiTest.resetEvents()
+
+	done := make(chan error, 1)
+	go func() { done <- apiTest.sendRequest("GET", "/events", "") }()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "subscribe") {
+			t.Errorf("Expected an event stream error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected sending a request to an event stream not to hang")
+	}
+
+	if err := apiTest.iSubscribeTo("/events"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iShouldReceiveAnEventWithin("message", "2s"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iStoreTheEventDataAs("greeting"); err != nil || apiTest.store["greeting"] != "hello" {
+		t.Errorf("Expected the event data to be stored, got %v (%v)", apiTest.store["greeting"], err)
+	}
+
+	// The draft order arrives first but does not match.
+	err := apiTest.iShouldReceiveAnEventWithinWithDataContaining("order.created", "2s", `{"id": ${order_id}, "tenant": "acme"}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iStoreTheEventIDAs("last_event_id"); err != nil || apiTest.store["last_event_id"] != "2" {
+		t.Errorf("Expected the event id to be stored, got %v (%v)", apiTest.store["last_event_id"], err)
+	}
+	if err := apiTest.iStoreTheEventPropertyAs("status", "status"); err != nil || apiTest.store["status"] != "pending" {
+		t.Errorf("Expected the event property to be stored, got %v (%v)", apiTest.store["status"], err)
+	}
+
+	err = apiTest.iShouldReceiveAnEventWithinWithDataContaining("order.created", "50ms", `{"status": "paid"}`)
+	if err == nil || !strings.Contains(err.Error(), "not matching") {
+		t.Errorf("Expected the unmatched draft order in the error, got %v", err)
+	}
+}
@@ -15,18 +15,7 @@ func (a *APITest) iStoreTheResponsePropertyAs(property, variable string) error {
 		return fmt.Errorf("property %s not found in response %s", property, a.responseBody)
 	}
 
-	switch value.Type {
-	case gjson.String:
-		a.store[variable] = value.String()
-	case gjson.Number:
-		a.store[variable] = value.Float()
-	case gjson.True, gjson.False:
-		a.store[variable] = value.Bool()
-	case gjson.JSON:
-		a.store[variable] = value.Raw
-	default:
-		a.store[variable] = value.Raw
-	}
+	a.store[variable] = storedValue(value)
 
 	if a.debug {
 		fmt.Printf("Stored property %s as %s: %v\n", property, variable, a.store[variable])
@@ -35,6 +24,20 @@ func (a *APITest) iStoreTheResponsePropertyAs(property, variable string) error {
 	return nil
 }
 
+// storedValue converts a JSON value to the type it is stored as.
+func storedValue(value gjson.Result) any {
+	switch value.Type {
+	case gjson.String:
+		return value.String()
+	case gjson.Number:
+		return value.Float()
+	case gjson.True, gjson.False:
+		return value.Bool()
+	default:
+		return value.Raw
+	}
+}
+
 func (a *APITest) iStoreTheCommandOutputAs(variable string) error {
 	if a.commandOutput == "" {
 		return fmt.Errorf("command output is empty")
@@ -47,7 +50,10 @@ func (a *APITest) iStoreTheCommandOutputAs(variable string) error {
 }
 
 func (a *APITest) iStoreAs(value, variable string) error {
-	value = a.replaceVars(value)
+	value, err := a.replaceVars(value)
+	if err != nil {
+		return err
+	}
 	if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
 		value = value[1 : len(value)-1]
 	}
@@ -78,8 +84,28 @@ func (a *APITest) iStoreAs(value, variable string) error {
 	return nil
 }
 
+// iStoreTheStringAs stores a value as a string, without converting numbers,
+// booleans or JSON.
+func (a *APITest) iStoreTheStringAs(value, variable string) error {
+	value, err := a.replaceVars(value)
+	if err != nil {
+		return err
+	}
+	a.store[variable] = value
+
+	if a.debug {
+		fmt.Printf("Stored string as %s: %s\n", variable, value)
+	}
+
+	return nil
+}
+
 func (a *APITest) iSetHeaderTo(header, value string) error {
-	a.headers[header] = a.replaceVars(value)
+	value, err := a.replaceVars(value)
+	if err != nil {
+		return err
+	}
+	a.headers[header] = value
 	if a.debug {
 		fmt.Printf("Set header %s to %s\n", header, a.headers[header])
 	}
@@ -0,0 +1,750 @@
+package app
+
+// Step categories, in the order they are documented.
+const (
+	CategoryRequests   = "Making requests"
+	CategoryResponses  = "Validating responses"
+	CategoryState      = "Managing state"
+	CategoryGeneration = "Data generation"
+	CategoryCommands   = "Command execution"
+	CategoryMocks      = "Mocking services"
+	CategoryWebhooks   = "Receiving webhooks"
+	CategoryWebSockets = "WebSocket conversations"
+	CategoryEvents     = "Server-sent events"
+	CategoryDebugging  = "Debugging"
+)
+
+// StepDefinition describes a step available in feature files. The registry
+// is the single source for both godog registration and `rbdd syntax`.
+type StepDefinition struct {
+	Pattern     string `json:"pattern"`
+	Syntax      string `json:"syntax"`
+	Description string `json:"description"`
+	Example     string `json:"example"`
+	Category    string `json:"category"`
+
+	handler func(a *APITest) any
+
+	// defines lists the variables a step stores, given its arguments, so
+	// `rbdd lint` can tell which ${...} references are set beforehand.
+	defines func(args []string) []string
+
+	// rawDocString marks steps whose docstring is not JSON, such as GraphQL
+	// queries, so lint does not check it as JSON.
+	rawDocString bool
+}
+
+// Steps returns every available step in registration order.
+func Steps() []StepDefinition {
+	return steps
+}
+
+var steps = []StepDefinition{
+	// Request steps
+	{
+		Pattern:     `^I send a "([^"]*)" request to "([^"]*)"$`,
+		Syntax:      `I send a "METHOD" request to "ENDPOINT"`,
+		Description: "This step sends a request to the specified endpoint using the specified HTTP method (GET, POST, PUT, DELETE, etc.).",
+		Example:     `When I send a "GET" request to "/api/users"`,
+		Category:    CategoryRequests,
+		handler:     func(a *APITest) any { return a.iSendRequestTo },
+		defines:     definesResponseTime,
+	},
+	{
+		Pattern:     `^I send a "([^"]*)" request to "([^"]*)" with payload:$`,
+		Syntax:      `I send a "METHOD" request to "ENDPOINT" with payload:`,
+		Description: "This step sends a request to the specified endpoint using the specified HTTP method with the provided payload.",
+		Example: `When I send a "POST" request to "/api/users" with payload:
+  """
+  {
+    "name": "John Doe",
+    "email": "john@example.com"
+  }
+  """`,
+		Category: CategoryRequests,
+		handler:  func(a *APITest) any { return a.iSendRequestToWithPayload },
+		defines:  definesResponseTime,
+	},
+	{
+		Pattern:     `^I send a GraphQL query to "([^"]*)":$`,
+		Syntax:      `I send a GraphQL query to "ENDPOINT":`,
+		Description: "This step posts a GraphQL query in the standard envelope, with any variables set beforehand. Errors in the response fail the scenario unless a GraphQL error step expects them.",
+		Example: `When I send a GraphQL query to "/graphql":
+  """
+  query User($id: ID!) {
+    user(id: $id) { id name }
+  }
+  """`,
+		Category:     CategoryRequests,
+		handler:      func(a *APITest) any { return a.iSendAGraphQLQueryTo },
+		defines:      definesResponseTime,
+		rawDocString: true,
+	},
+	{
+		Pattern:     `^I call gRPC "([^"]*)"$`,
+		Syntax:      `I call gRPC "package.Service/Method"`,
+		Description: "This step calls a unary gRPC method with an empty request. Headers are sent as metadata and the response is stored as JSON.",
+		Example:     `When I call gRPC "users.v1.UserService/ListUsers"`,
+		Category:    CategoryRequests,
+		handler:     func(a *APITest) any { return a.iCallGRPC },
+	},
+	{
+		Pattern:     `^I call gRPC "([^"]*)" with:$`,
+		Syntax:      `I call gRPC "package.Service/Method" with:`,
+		Description: "This step calls a unary gRPC method with a JSON request, resolving it from the configured proto files or by server reflection. Headers are sent as metadata and the response is stored as JSON.",
+		Example: `When I call gRPC "users.v1.UserService/GetUser" with:
+  """
+  { "id": "${user_id}" }
+  """`,
+		Category: CategoryRequests,
+		handler:  func(a *APITest) any { return a.iCallGRPCWith },
+	},
+
+	// Response validation steps
+	{
+		Pattern:     `^the response status should be (\d+)$`,
+		Syntax:      `the response status should be STATUS_CODE`,
+		Description: "This step checks if the response status code matches the expected status code.",
+		Example:     `Then the response status should be 200`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theResponseStatusShouldBe },
+	},
+	{
+		Pattern:     `^the response property "([^"]*)" should be (.*?)$`,
+		Syntax:      `the response property "JSON_PATH" should be VALUE`,
+		Description: "This step checks if the response property at the specified JSON path matches the expected value.",
+		Example:     `Then the response property "data.user.email" should be "john@example.com"`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theResponsePropertyShouldBe },
+	},
+	{
+		Pattern:     `^the response property "([^"]*)" should not be empty$`,
+		Syntax:      `the response property "JSON_PATH" should not be empty`,
+		Description: "This step checks if the response property at the specified JSON path is not empty.",
+		Example:     `Then the response property "data.user.id" should not be empty`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theResponsePropertyShouldNotBeEmpty },
+	},
+	{
+		Pattern:     `^the response should match JSON:$`,
+		Syntax:      `the response should match JSON:`,
+		Description: "This step checks if the entire response matches the expected JSON structure.",
+		Example: `Then the response should match JSON:
+  """
+  {
+    "success": true,
+    "data": {
+      "id": 123,
+      "name": "John Doe"
+    }
+  }
+  """`,
+		Category: CategoryResponses,
+		handler:  func(a *APITest) any { return a.theResponseShouldMatchJSON },
+	},
+	{
+		Pattern:     `^the response should contain JSON:$`,
+		Syntax:      `the response should contain JSON:`,
+		Description: "This step checks if the response contains the specified JSON structure.",
+		Example: `Then the response should contain JSON:
+  """
+  {
+    "success": true
+  }
+  """`,
+		Category: CategoryResponses,
+		handler:  func(a *APITest) any { return a.theResponseShouldContainJSON },
+	},
+	{
+		Pattern:     `^the response should match schema "([^"]*)"$`,
+		Syntax:      `the response should match schema "FILE"`,
+		Description: `This step validates the response against a JSON Schema file (JSON or YAML). A "#/..." fragment selects a schema inside the file, such as a response schema in an OpenAPI document.`,
+		Example:     `Then the response should match schema "api.yaml#/components/schemas/User"`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theResponseShouldMatchSchema },
+	},
+
+	{
+		Pattern:     `^the response time should be below (\d+(?:ms|s))$`,
+		Syntax:      `the response time should be below DURATION`,
+		Description: "This step checks that the last request took less than the duration, from sending it to reading the whole response. The failure shows the time spent in DNS, connecting, TLS and waiting for the first byte.",
+		Example:     `Then the response time should be below 300ms`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theResponseTimeShouldBeBelow },
+	},
+
+	// gRPC response steps
+	{
+		Pattern:     `^the gRPC status should be "([^"]*)"$`,
+		Syntax:      `the gRPC status should be "CODE"`,
+		Description: "This step checks the status of the last gRPC call, by name such as NOT_FOUND or by number.",
+		Example:     `Then the gRPC status should be "NOT_FOUND"`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theGRPCStatusShouldBe },
+	},
+	{
+		Pattern:     `^the gRPC status message should be "([^"]*)"$`,
+		Syntax:      `the gRPC status message should be "MESSAGE"`,
+		Description: "This step checks the status message of the last gRPC call.",
+		Example:     `Then the gRPC status message should be "user not found"`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theGRPCStatusMessageShouldBe },
+	},
+	{
+		Pattern:     `^the gRPC (header|trailer) "([^"]*)" should be "([^"]*)"$`,
+		Syntax:      `the gRPC header|trailer "KEY" should be "VALUE"`,
+		Description: "This step checks a metadata value the server sent in the response headers or trailers of the last gRPC call.",
+		Example:     `Then the gRPC trailer "x-request-id" should be "${request_id}"`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theGRPCMetadataShouldBe },
+	},
+
+	// GraphQL response steps
+	{
+		Pattern:     `^the GraphQL response should have no errors$`,
+		Syntax:      `the GraphQL response should have no errors`,
+		Description: "This step checks that the GraphQL response has no errors, listing their messages otherwise.",
+		Example:     `Then the GraphQL response should have no errors`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theGraphQLResponseShouldHaveNoErrors },
+	},
+	{
+		Pattern:     `^the GraphQL response should have errors$`,
+		Syntax:      `the GraphQL response should have errors`,
+		Description: "This step checks that the GraphQL response has errors, expecting them so they do not fail the scenario.",
+		Example:     `Then the GraphQL response should have errors`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theGraphQLResponseShouldHaveErrors },
+	},
+	{
+		Pattern:     `^the GraphQL error "([^"]*)" should be (.*?)$`,
+		Syntax:      `the GraphQL error "JSON_PATH" should be VALUE`,
+		Description: "This step checks that an error in the GraphQL response has a property with the expected value, expecting the errors so they do not fail the scenario.",
+		Example:     `Then the GraphQL error "extensions.code" should be "FORBIDDEN"`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theGraphQLErrorShouldBe },
+	},
+
+	// XML response steps
+	{
+		Pattern:     `^the response XPath "([^"]*)" should be (.*?)$`,
+		Syntax:      `the response XPath "XPATH" should be VALUE`,
+		Description: "This step checks if the value of the first node selected by an XPath expression, or the expression's result, matches the expected value. Prefixes declared in the response can be used in the expression.",
+		Example:     `Then the response XPath "//order/@id" should be "42"`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theResponseXPathShouldBe },
+	},
+	{
+		Pattern:     `^the response XPath "([^"]*)" should not be empty$`,
+		Syntax:      `the response XPath "XPATH" should not be empty`,
+		Description: "This step checks if an XPath expression selects a node with a non-empty value.",
+		Example:     `Then the response XPath "//soap:Body/m:OrderResponse/m:Reference" should not be empty`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theResponseXPathShouldNotBeEmpty },
+	},
+	{
+		Pattern:     `^the response should match XML:$`,
+		Syntax:      `the response should match XML:`,
+		Description: "This step checks if the response is the same XML document, ignoring whitespace between elements, attribute order and namespace prefixes.",
+		Example: `Then the response should match XML:
+  """
+  <order id="${order_id}">
+    <status>paid</status>
+  </order>
+  """`,
+		Category: CategoryResponses,
+		handler:  func(a *APITest) any { return a.theResponseShouldMatchXML },
+	},
+	{
+		Pattern:     `^the response should match XSD "([^"]*)"$`,
+		Syntax:      `the response should match XSD "FILE"`,
+		Description: "This step validates the XML response against an XML Schema file, including the files it includes or imports.",
+		Example:     `Then the response should match XSD "schemas/order.xsd"`,
+		Category:    CategoryResponses,
+		handler:     func(a *APITest) any { return a.theResponseShouldMatchXSD },
+	},
+
+	// State management steps
+	{
+		Pattern:     `^I store the response property "([^"]*)" as "([^"]*)"$`,
+		Syntax:      `I store the response property "JSON_PATH" as "VARIABLE_NAME"`,
+		Description: "This step stores the value of the response property at the specified JSON path into a variable.",
+		Example:     `And I store the response property "data.token" as "auth_token"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iStoreTheResponsePropertyAs },
+		defines:     definesArg(1),
+	},
+	{
+		Pattern:     `^I store the command output as "([^"]*)"$`,
+		Syntax:      `I store the command output as "VARIABLE_NAME"`,
+		Description: "This step stores the output of a command into a variable.",
+		Example:     `And I store the command output as "db_result"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iStoreTheCommandOutputAs },
+		defines:     definesArg(0),
+	},
+	{
+		Pattern:     `^I store "([^"]*)" as "([^"]*)"$`,
+		Syntax:      `I store "VALUE" as "VARIABLE_NAME"`,
+		Description: "This step stores a specified value into a variable.",
+		Example:     `And I store "Bearer ${auth_token}" as "authorization"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iStoreAs },
+		defines:     definesArg(1),
+	},
+	{
+		Pattern:     `^I store the string "([^"]*)" as "([^"]*)"$`,
+		Syntax:      `I store the string "VALUE" as "VARIABLE_NAME"`,
+		Description: "This step stores a value into a variable as a string, without converting numbers such as 007 or booleans.",
+		Example:     `Given I store the string "007" as "agent_id"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iStoreTheStringAs },
+		defines:     definesArg(1),
+	},
+	{
+		Pattern:     `^I store the contents of "([^"]*)" as "([^"]*)"$`,
+		Syntax:      `I store the contents of "FILE" as "VARIABLE_NAME"`,
+		Description: "This step stores the contents of a file into a variable, decoding JSON and YAML files into typed values.",
+		Example:     `Given I store the contents of "fixtures/order.json" as "order"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iStoreTheContentsOfAs },
+		defines:     definesArg(1),
+	},
+	{
+		Pattern:     `^I store the following as "([^"]*)":$`,
+		Syntax:      `I store the following as "VARIABLE_NAME":`,
+		Description: "This step stores a docstring into a variable, decoding it if it is valid JSON.",
+		Example: `Given I store the following as "order":
+  """
+  {
+    "items": [{"sku": "A1", "qty": 2}]
+  }
+  """`,
+		Category: CategoryState,
+		handler:  func(a *APITest) any { return a.iStoreTheFollowingAs },
+		defines:  definesArg(0),
+	},
+	{
+		Pattern:     `^I load variables from "([^"]*)"$`,
+		Syntax:      `I load variables from "FILE"`,
+		Description: "This step loads every variable defined in a .env, JSON or YAML file into the store.",
+		Example:     `Given I load variables from "fixtures/users.yaml"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iLoadVariablesFrom },
+		defines:     definesVariablesFile,
+	},
+	{
+		Pattern:     `^I load environment variables "([^"]*)"$`,
+		Syntax:      `I load environment variables "NAME_LIST"`,
+		Description: "This step copies the listed environment variables into the store.",
+		Example:     `Given I load environment variables "API_KEY, TENANT_ID"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iLoadEnvironmentVariables },
+		defines:     definesEnvironmentVariables,
+	},
+	{
+		Pattern:     `^I store the response XPath "([^"]*)" as "([^"]*)"$`,
+		Syntax:      `I store the response XPath "XPATH" as "VARIABLE_NAME"`,
+		Description: "This step stores the value of the first node selected by an XPath expression, or the expression's result, into a variable.",
+		Example:     `And I store the response XPath "//order/@id" as "order_id"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iStoreTheResponseXPathAs },
+		defines:     definesArg(1),
+	},
+	{
+		Pattern:     `^I use the XML namespace "([^"]*)" for "([^"]*)"$`,
+		Syntax:      `I use the XML namespace "PREFIX" for "URI"`,
+		Description: "This step maps a prefix to a namespace URI for XPath expressions, taking precedence over the prefixes declared in responses.",
+		Example:     `Given I use the XML namespace "soap" for "http://schemas.xmlsoap.org/soap/envelope/"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iUseTheXMLNamespaceFor },
+	},
+	{
+		Pattern:     `^I use the gRPC server "([^"]*)"$`,
+		Syntax:      `I use the gRPC server "ADDRESS"`,
+		Description: "This step sets the gRPC server to call, as host:port or an http:// or https:// URL. https:// uses TLS.",
+		Example:     `Given I use the gRPC server "localhost:50051"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iUseTheGRPCServer },
+	},
+	{
+		Pattern:     `^I use the proto file "([^"]*)"$`,
+		Syntax:      `I use the proto file "FILE"`,
+		Description: "This step resolves gRPC methods from a .proto file instead of server reflection. Imports are found in the configured proto_import_paths.",
+		Example:     `Given I use the proto file "protos/users/v1/users.proto"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iUseTheProtoFile },
+	},
+	{
+		Pattern:     `^I set the GraphQL variables:$`,
+		Syntax:      `I set the GraphQL variables:`,
+		Description: "This step sets the variables of the next GraphQL query from a table of names and values. Values that are valid JSON keep their type; quote a value to send it as a string.",
+		Example: `Given I set the GraphQL variables:
+  | id    | ${user_id} |
+  | admin | true       |`,
+		Category: CategoryState,
+		handler:  func(a *APITest) any { return a.iSetTheGraphQLVariables },
+	},
+	{
+		Pattern:     `^I set the GraphQL variables to:$`,
+		Syntax:      `I set the GraphQL variables to:`,
+		Description: "This step sets the variables of the next GraphQL query from a JSON object.",
+		Example: `Given I set the GraphQL variables to:
+  """
+  { "input": { "name": "${name}", "tags": ["a", "b"] } }
+  """`,
+		Category: CategoryState,
+		handler:  func(a *APITest) any { return a.iSetTheGraphQLVariablesTo },
+	},
+	{
+		Pattern:     `^I set header "([^"]*)" to "([^"]*)"$`,
+		Syntax:      `I set header "HEADER_NAME" to "HEADER_VALUE"`,
+		Description: "This step sets a specified header to a specified value.",
+		Example:     `And I set header "Authorization" to "${authorization}"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iSetHeaderTo },
+	},
+	{
+		Pattern:     `^I reset all variables$`,
+		Syntax:      `I reset all variables`,
+		Description: "This step resets all stored variables to their initial state.",
+		Example:     `And I reset all variables`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iResetAllVariables },
+	},
+	{
+		Pattern:     `^I reset variables "([^"]*)"$`,
+		Syntax:      `I reset variables "VARIABLE_LIST"`,
+		Description: "This step resets specified variables to their initial state.",
+		Example:     `And I reset variables "user_id, auth_token"`,
+		Category:    CategoryState,
+		handler:     func(a *APITest) any { return a.iResetVariables },
+	},
+
+	// Data generation steps
+	{
+		Pattern:     `^I generate fake data: "([^"]*)"$`,
+		Syntax:      `I generate fake data: "PATTERN"`,
+		Description: "This step generates fake data based on the specified pattern using the gofakeit library. Append !unique to a pattern to never repeat a value for that variable during the run.",
+		Example:     `Given I generate fake data: "email={email}!unique, name={firstname} {lastname}, phone={phone}"`,
+		Category:    CategoryGeneration,
+		handler:     func(a *APITest) any { return a.iGenerateFakeData },
+		defines:     definesFakeData(0),
+	},
+	{
+		Pattern:     `^I generate fake data in locale "([^"]*)": "([^"]*)"$`,
+		Syntax:      `I generate fake data in locale "LOCALE": "PATTERN"`,
+		Description: "This step generates fake data with names, addresses and phone numbers from the given locale (de, fr).",
+		Example:     `Given I generate fake data in locale "de": "name={name}, street={street}, city={city}, zip={zip}, phone={phone}"`,
+		Category:    CategoryGeneration,
+		handler:     func(a *APITest) any { return a.iGenerateFakeDataInLocale },
+		defines:     definesFakeData(1),
+	},
+	{
+		Pattern:     `^I generate a fake "([^"]*)" from JSON template:$`,
+		Syntax:      `I generate a fake "VARIABLE_NAME" from JSON template:`,
+		Description: `This step generates a JSON object from a template whose strings contain gofakeit tags. An array starting with "{repeat:N}" or "{repeat:MIN,MAX}" repeats its second element. Single numeric or boolean tags keep their type.`,
+		Example: `Given I generate a fake "user" from JSON template:
+  """
+  {
+    "email": "{email}",
+    "age": "{number:18,65}",
+    "addresses": ["{repeat:3}", {"city": "{city}"}]
+  }
+  """`,
+		Category: CategoryGeneration,
+		handler:  func(a *APITest) any { return a.iGenerateAFakeFromJSONTemplate },
+		defines:  definesArg(0),
+	},
+	{
+		Pattern:     `^I generate fake data matching schema "([^"]*)" as "([^"]*)"$`,
+		Syntax:      `I generate fake data matching schema "FILE" as "VARIABLE_NAME"`,
+		Description: `This step generates an object matching a JSON Schema file (JSON or YAML), using formats, patterns, enums, ranges, field names and "x-faker" tags to pick realistic values. A "#/..." fragment selects a schema inside the file.`,
+		Example:     `Given I generate fake data matching schema "schemas/order.json" as "order"`,
+		Category:    CategoryGeneration,
+		handler:     func(a *APITest) any { return a.iGenerateFakeDataMatchingSchemaAs },
+		defines:     definesArg(1),
+	},
+
+	// Command execution steps
+	{
+		Pattern:     `^I execute command "([^"]*)"$`,
+		Syntax:      `I execute command "COMMAND"`,
+		Description: "This step executes a specified command in the shell.",
+		Example:     `When I execute command "echo 'Hello World'"`,
+		Category:    CategoryCommands,
+		handler:     func(a *APITest) any { return a.iExecuteCommand },
+	},
+	{
+		Pattern:     `^I execute command "([^"]*)" in directory "([^"]*)"$`,
+		Syntax:      `I execute command "COMMAND" in directory "DIRECTORY"`,
+		Description: "This step executes a specified command in the shell within a specified directory.",
+		Example:     `When I execute command "npm install" in directory "./frontend"`,
+		Category:    CategoryCommands,
+		handler:     func(a *APITest) any { return a.iExecuteCommandInDirectory },
+	},
+	{
+		Pattern:     `^I execute command "([^"]*)" with timeout (\d+)$`,
+		Syntax:      `I execute command "COMMAND" with timeout SECONDS`,
+		Description: "This step executes a specified command in the shell with a specified timeout in seconds.",
+		Example:     `When I execute command "gradle build" with timeout 30`,
+		Category:    CategoryCommands,
+		handler:     func(a *APITest) any { return a.iExecuteCommandWithTimeout },
+	},
+	{
+		Pattern:     `^the command output should match "([^"]*)"$`,
+		Syntax:      `the command output should match "PATTERN"`,
+		Description: "This step checks if the command output matches the specified pattern.",
+		Example:     `Then the command output should match "Success"`,
+		Category:    CategoryCommands,
+		handler:     func(a *APITest) any { return a.theCommandOutputShouldMatch },
+	},
+	{
+		Pattern:     `^the command output should contain "([^"]*)"$`,
+		Syntax:      `the command output should contain "TEXT"`,
+		Description: "This step checks if the command output contains the specified text.",
+		Example:     `Then the command output should contain "Build completed"`,
+		Category:    CategoryCommands,
+		handler:     func(a *APITest) any { return a.theCommandOutputShouldContain },
+	},
+
+	// Mock server steps
+	{
+		Pattern:     `^the mock "([^"]*)" responds to "([^"]*)" with status (\d+)$`,
+		Syntax:      `the mock "NAME" responds to "METHOD PATH" with status STATUS_CODE`,
+		Description: "This step starts an in-process HTTP server standing in for a third-party API, if it is not running yet, and makes it respond to a route with an empty body. Its URL is available as ${mock.NAME.url} and it is stopped at the end of the scenario.",
+		Example:     `Given the mock "payments" responds to "DELETE /charges/ch_1" with status 204`,
+		Category:    CategoryMocks,
+		handler:     func(a *APITest) any { return a.theMockRespondsToWithStatus },
+		defines:     definesMock,
+	},
+	{
+		Pattern:     `^the mock "([^"]*)" responds to "([^"]*)" with status (\d+) and body:$`,
+		Syntax:      `the mock "NAME" responds to "METHOD PATH" with status STATUS_CODE and body:`,
+		Description: "This step makes a mock server respond to a route with the given body, which may contain ${...} placeholders. JSON bodies are sent with a JSON content type.",
+		Example: `Given the mock "payments" responds to "POST /charges" with status 201 and body:
+  """
+  {
+    "id": "ch_1",
+    "status": "succeeded"
+  }
+  """`,
+		Category: CategoryMocks,
+		handler:  func(a *APITest) any { return a.theMockRespondsToWithStatusAndBody },
+		defines:  definesMock,
+	},
+	{
+		Pattern:     `^the mock "([^"]*)" should have received (\d+) "([^"]*)" requests? to "([^"]*)"$`,
+		Syntax:      `the mock "NAME" should have received COUNT "METHOD" request(s) to "PATH"`,
+		Description: "This step checks how many requests a mock server received for a method and path.",
+		Example:     `Then the mock "payments" should have received 1 "POST" request to "/charges"`,
+		Category:    CategoryMocks,
+		handler:     func(a *APITest) any { return a.theMockShouldHaveReceivedRequestsTo },
+	},
+	{
+		Pattern:     `^the mock "([^"]*)" should have received (\d+) "([^"]*)" requests? to "([^"]*)" with JSON containing:$`,
+		Syntax:      `the mock "NAME" should have received COUNT "METHOD" request(s) to "PATH" with JSON containing:`,
+		Description: "This step checks how many requests a mock server received for a method and path whose JSON body contains the specified structure.",
+		Example: `Then the mock "payments" should have received 1 "POST" request to "/charges" with JSON containing:
+  """
+  {
+    "amount": 1000
+  }
+  """`,
+		Category: CategoryMocks,
+		handler:  func(a *APITest) any { return a.theMockShouldHaveReceivedRequestsToWithJSONContaining },
+	},
+
+	// Webhook steps
+	{
+		Pattern:     `^I listen for webhooks on "([^"]*)"$`,
+		Syntax:      `I listen for webhooks on "VARIABLE_NAME"`,
+		Description: "This step starts a local webhook listener for the scenario and stores its URL in a variable, given as a name or a ${...} reference, to pass to the API.",
+		Example:     `Given I listen for webhooks on "${webhook_url}"`,
+		Category:    CategoryWebhooks,
+		handler:     func(a *APITest) any { return a.iListenForWebhooksOn },
+		defines:     definesWebhookVariable,
+	},
+	{
+		Pattern:     `^I should receive a webhook within (\d+(?:ms|s|m))$`,
+		Syntax:      `I should receive a webhook within DURATION`,
+		Description: "This step waits for a webhook that has not been matched by an earlier step. With a webhook secret configured, webhooks without a valid signature are ignored.",
+		Example:     `Then I should receive a webhook within 10s`,
+		Category:    CategoryWebhooks,
+		handler:     func(a *APITest) any { return a.iShouldReceiveAWebhookWithin },
+	},
+	{
+		Pattern:     `^I should receive a webhook within (\d+(?:ms|s|m)) with JSON containing:$`,
+		Syntax:      `I should receive a webhook within DURATION with JSON containing:`,
+		Description: "This step waits for a webhook whose JSON body contains the specified structure.",
+		Example: `Then I should receive a webhook within 10s with JSON containing:
+  """
+  {
+    "event": "order.paid"
+  }
+  """`,
+		Category: CategoryWebhooks,
+		handler:  func(a *APITest) any { return a.iShouldReceiveAWebhookWithinWithJSONContaining },
+	},
+	{
+		Pattern:     `^I store the webhook property "([^"]*)" as "([^"]*)"$`,
+		Syntax:      `I store the webhook property "JSON_PATH" as "VARIABLE_NAME"`,
+		Description: "This step stores the value at the specified JSON path of the last received webhook into a variable.",
+		Example:     `And I store the webhook property "data.order_id" as "order_id"`,
+		Category:    CategoryWebhooks,
+		handler:     func(a *APITest) any { return a.iStoreTheWebhookPropertyAs },
+		defines:     definesArg(1),
+	},
+
+	// WebSocket steps
+	{
+		Pattern:     `^I connect to the WebSocket "([^"]*)"$`,
+		Syntax:      `I connect to the WebSocket "ENDPOINT"`,
+		Description: "This step opens a WebSocket connection, relative to the base URL with http switched to ws, sending the headers set so far. Messages are collected until the end of the scenario.",
+		Example:     `When I connect to the WebSocket "/ws/orders"`,
+		Category:    CategoryWebSockets,
+		handler:     func(a *APITest) any { return a.iConnectToTheWebSocket },
+	},
+	{
+		Pattern:     `^I send the WebSocket message "([^"]*)"$`,
+		Syntax:      `I send the WebSocket message "TEXT"`,
+		Description: "This step sends a text message on the WebSocket connection.",
+		Example:     `And I send the WebSocket message "ping"`,
+		Category:    CategoryWebSockets,
+		handler:     func(a *APITest) any { return a.iSendTheWebSocketMessage },
+	},
+	{
+		Pattern:     `^I send the WebSocket message:$`,
+		Syntax:      `I send the WebSocket message:`,
+		Description: "This step sends a docstring as a text message on the WebSocket connection, rendering placeholders in JSON like request payloads.",
+		Example: `And I send the WebSocket message:
+  """
+  { "type": "subscribe", "channel": "orders.${user_id}" }
+  """`,
+		Category: CategoryWebSockets,
+		handler:  func(a *APITest) any { return a.iSendTheWebSocketMessage },
+	},
+	{
+		Pattern:     `^I should receive a WebSocket message within (\d+(?:ms|s|m))$`,
+		Syntax:      `I should receive a WebSocket message within DURATION`,
+		Description: "This step waits for the next WebSocket message. Each message is matched by one step.",
+		Example:     `Then I should receive a WebSocket message within 2s`,
+		Category:    CategoryWebSockets,
+		handler:     func(a *APITest) any { return a.iShouldReceiveAWebSocketMessageWithin },
+	},
+	{
+		Pattern:     `^I should receive a WebSocket message where (.+) within (\d+(?:ms|s|m))$`,
+		Syntax:      `I should receive a WebSocket message where CONDITION within DURATION`,
+		Description: "This step waits for a WebSocket message matching a gjson condition, such as a comparison of a property with a value. Messages that do not match remain available to later steps.",
+		Example:     `Then I should receive a WebSocket message where type == "order.created" within 2s`,
+		Category:    CategoryWebSockets,
+		handler:     func(a *APITest) any { return a.iShouldReceiveAWebSocketMessageWhereWithin },
+	},
+	{
+		Pattern:     `^I store the WebSocket message property "([^"]*)" as "([^"]*)"$`,
+		Syntax:      `I store the WebSocket message property "JSON_PATH" as "VARIABLE_NAME"`,
+		Description: "This step stores a property of the last WebSocket message received into a variable.",
+		Example:     `And I store the WebSocket message property "data.id" as "order_id"`,
+		Category:    CategoryWebSockets,
+		handler:     func(a *APITest) any { return a.iStoreTheWebSocketMessagePropertyAs },
+		defines:     definesArg(1),
+	},
+	{
+		Pattern:     `^I close the WebSocket$`,
+		Syntax:      `I close the WebSocket`,
+		Description: "This step closes the WebSocket connection with a normal closure, code 1000.",
+		Example:     `When I close the WebSocket`,
+		Category:    CategoryWebSockets,
+		handler:     func(a *APITest) any { return a.iCloseTheWebSocket },
+	},
+	{
+		Pattern:     `^the WebSocket should close with code (\d+) within (\d+(?:ms|s|m))$`,
+		Syntax:      `the WebSocket should close with code CODE within DURATION`,
+		Description: "This step waits for the WebSocket connection to close and checks its close code. A connection dropped without a close frame has code 1006.",
+		Example:     `Then the WebSocket should close with code 4001 within 1s`,
+		Category:    CategoryWebSockets,
+		handler:     func(a *APITest) any { return a.theWebSocketShouldCloseWithCodeWithin },
+	},
+
+	// Server-sent event steps
+	{
+		Pattern:     `^I subscribe to "([^"]*)"$`,
+		Syntax:      `I subscribe to "ENDPOINT"`,
+		Description: "This step opens a Server-Sent Events stream with a GET request, sending the headers set so far. Events are collected until the end of the scenario.",
+		Example:     `When I subscribe to "/events"`,
+		Category:    CategoryEvents,
+		handler:     func(a *APITest) any { return a.iSubscribeTo },
+	},
+	{
+		Pattern:     `^I should receive an event "([^"]*)" within (\d+(?:ms|s|m))$`,
+		Syntax:      `I should receive an event "NAME" within DURATION`,
+		Description: `This step waits for an event with the name, "message" for events without one. Each event is matched by one step.`,
+		Example:     `Then I should receive an event "order.created" within 5s`,
+		Category:    CategoryEvents,
+		handler:     func(a *APITest) any { return a.iShouldReceiveAnEventWithin },
+	},
+	{
+		Pattern:     `^I should receive an event "([^"]*)" within (\d+(?:ms|s|m)) with data containing:$`,
+		Syntax:      `I should receive an event "NAME" within DURATION with data containing:`,
+		Description: "This step waits for an event with the name whose JSON data contains the expected subset. Events that do not match remain available to later steps.",
+		Example: `Then I should receive an event "order.created" within 5s with data containing:
+  """
+  { "id": ${order_id}, "status": "pending" }
+  """`,
+		Category: CategoryEvents,
+		handler:  func(a *APITest) any { return a.iShouldReceiveAnEventWithinWithDataContaining },
+	},
+	{
+		Pattern:     `^I store the event id as "([^"]*)"$`,
+		Syntax:      `I store the event id as "VARIABLE_NAME"`,
+		Description: "This step stores the id of the last event received into a variable, such as to resume the stream with a Last-Event-ID header.",
+		Example:     `And I store the event id as "last_event_id"`,
+		Category:    CategoryEvents,
+		handler:     func(a *APITest) any { return a.iStoreTheEventIDAs },
+		defines:     definesArg(0),
+	},
+	{
+		Pattern:     `^I store the event data as "([^"]*)"$`,
+		Syntax:      `I store the event data as "VARIABLE_NAME"`,
+		Description: "This step stores the data of the last event received into a variable.",
+		Example:     `And I store the event data as "order"`,
+		Category:    CategoryEvents,
+		handler:     func(a *APITest) any { return a.iStoreTheEventDataAs },
+		defines:     definesArg(0),
+	},
+	{
+		Pattern:     `^I store the event property "([^"]*)" as "([^"]*)"$`,
+		Syntax:      `I store the event property "JSON_PATH" as "VARIABLE_NAME"`,
+		Description: "This step stores a property of the last event's JSON data into a variable.",
+		Example:     `And I store the event property "id" as "order_id"`,
+		Category:    CategoryEvents,
+		handler:     func(a *APITest) any { return a.iStoreTheEventPropertyAs },
+		defines:     definesArg(1),
+	},
+
+	// Debugging steps
+	{
+		Pattern:     `^I start debugging$`,
+		Syntax:      `I start debugging`,
+		Description: "This step starts the debugging mode, allowing for detailed output during test execution.",
+		Example:     `Given I start debugging`,
+		Category:    CategoryDebugging,
+		handler:     func(a *APITest) any { return a.iStartDebugging },
+	},
+	{
+		Pattern:     `^I stop debugging$`,
+		Syntax:      `I stop debugging`,
+		Description: "This step stops the debugging mode.",
+		Example:     `Given I stop debugging`,
+		Category:    CategoryDebugging,
+		handler:     func(a *APITest) any { return a.iStopDebugging },
+	},
+	{
+		Pattern:     `^I print the last request as curl$`,
+		Syntax:      `I print the last request as curl`,
+		Description: "This step prints the last request of the scenario as a curl command, with its resolved method, headers and body. Failing request and response steps include it automatically.",
+		Example:     `Then I print the last request as curl`,
+		Category:    CategoryDebugging,
+		handler:     func(a *APITest) any { return a.iPrintTheLastRequestAsCurl },
+	},
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStepsExamplesMatchPatterns(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	seen := map[string]bool{}
+
+	for _, step := range Steps() {
+		if seen[step.Pattern] {
+			t.Errorf("Duplicate step pattern %q", step.Pattern)
+		}
+		seen[step.Pattern] = true
+
+		re, err := regexp.Compile(step.Pattern)
+		if err != nil {
+			t.Errorf("Invalid pattern %q: %v", step.Pattern, err)
+			continue
+		}
+
+		if step.Syntax == "" || step.Description == "" || step.Category == "" {
+			t.Errorf("Step %q is missing documentation", step.Pattern)
+		}
+		if step.handler(apiTest) == nil {
+			t.Errorf("Step %q has no handler", step.Pattern)
+		}
+
+		example, _, _ := strings.Cut(step.Example, "\n")
+		_, text, _ := strings.Cut(example, " ")
+		if !re.MatchString(text) {
+			t.Errorf("Example %q does not match pattern %q", example, step.Pattern)
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// pathParamPattern matches {name} segments in stub paths.
+var pathParamPattern = regexp.MustCompile(`^\{(\w+)\}$`)
+
+// Stub is a canned response served by a StubServer for the requests its
+// matchers accept.
+type Stub struct {
+	Name     string       `yaml:"name"`
+	Request  StubRequest  `yaml:"request"`
+	Response StubResponse `yaml:"response"`
+
+	pattern *regexp.Regexp
+}
+
+// StubRequest matches requests. Empty fields match anything. Path segments
+// written as {name} are captured and * matches any one segment, and query
+// and header values may use * wildcards. A body object must be contained in
+// the request's JSON body, a string body must equal it and any other body,
+// such as a list or number, must equal it as JSON.
+type StubRequest struct {
+	Method  string            `yaml:"method"`
+	Path    string            `yaml:"path"`
+	Query   map[string]string `yaml:"query"`
+	Headers map[string]string `yaml:"headers"`
+	Body    any               `yaml:"body"`
+}
+
+// StubResponse is the response to a matched request. Its header values and
+// body may contain ${...} placeholders, which can refer to the request as
+// request.method, request.path, request.params, request.query,
+// request.headers and request.body, and the body may contain fake data tags.
+type StubResponse struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers"`
+	Body    any               `yaml:"body"`
+	Delay   string            `yaml:"delay"`
+}
+
+// StubServer serves the stubs defined in the YAML and JSON files of a
+// directory.
+type StubServer struct {
+	dir string
+	cfg Config
+	log io.Writer
+
+	mu    sync.RWMutex
+	stubs []*Stub
+}
+
+// NewStubServer loads the stubs in dir. Responses are templated with cfg's
+// variables, locale and generators, and requests are logged to log if it is
+// not nil.
+func NewStubServer(dir string, cfg Config, log io.Writer) (*StubServer, error) {
+	s := &StubServer{dir: dir, cfg: cfg, log: log}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Len returns the number of stubs loaded.
+func (s *StubServer) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.stubs)
+}
+
+// Reload reads the stub files again, keeping the current stubs if any of
+// them is invalid.
+func (s *StubServer) Reload() error {
+	stubs, err := LoadStubs(s.dir)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.stubs = stubs
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the stubs whenever a file in the directory changes, until
+// done is closed. Reload errors are logged rather than returned, so a stub
+// file can be fixed without restarting.
+func (s *StubServer) Watch(done <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	err = filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			err = watcher.Add(p)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", s.dir, err)
+	}
+
+	// Editors write files in several steps, so reload once they settle.
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-done:
+			return nil
+		case event := <-watcher.Events:
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watcher.Add(event.Name)
+				}
+			}
+			reload = time.After(100 * time.Millisecond)
+		case err := <-watcher.Errors:
+			s.logf("Watch error: %v\n", err)
+		case <-reload:
+			if err := s.Reload(); err != nil {
+				s.logf("Keeping previous stubs: %v\n", err)
+			} else {
+				s.logf("Reloaded %d stubs\n", s.Len())
+			}
+		}
+	}
+}
+
+func (s *StubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.RLock()
+	stubs := s.stubs
+	s.mu.RUnlock()
+
+	for _, stub := range stubs {
+		params, ok := stub.match(r, string(body))
+		if !ok {
+			continue
+		}
+
+		status, err := s.respond(w, stub, r, string(body), params)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("stub %s: %v", stub.Name, err), http.StatusInternalServerError)
+			s.logf("%s %s -> 500 (%s: %v)\n", r.Method, r.URL.RequestURI(), stub.Name, err)
+			return
+		}
+		s.logf("%s %s -> %d (%s)\n", r.Method, r.URL.RequestURI(), status, stub.Name)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprintf(w, `{"error": %s}`, marshalJSON("no stub matches "+r.Method+" "+r.URL.Path))
+	s.logf("%s %s -> 404 (no matching stub)\n", r.Method, r.URL.RequestURI())
+}
+
+// respond writes the stub's response, rendering its templates against the
+// request.
+func (s *StubServer) respond(w http.ResponseWriter, stub *Stub, r *http.Request, body string, params map[string]string) (int, error) {
+	api := &APITest{
+		store:      map[string]any{},
+		faker:      gofakeit.New(0),
+		locale:     s.cfg.Locale,
+		generators: s.cfg.Generators,
+	}
+	for k, v := range s.cfg.Variables {
+		api.store[k] = normalizeValue(v)
+	}
+	api.store["request"] = stubRequestValues(r, body, params)
+
+	content, err := api.renderStubBody(stub.Response.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	if stub.Response.Delay != "" {
+		delay, err := time.ParseDuration(stub.Response.Delay)
+		if err != nil {
+			return 0, fmt.Errorf("invalid delay: %w", err)
+		}
+		time.Sleep(delay)
+	}
+
+	if isJSONPayload(content) {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	for k, v := range stub.Response.Headers {
+		value, err := api.replaceVars(v)
+		if err != nil {
+			return 0, err
+		}
+		w.Header().Set(k, value)
+	}
+
+	status := stub.Response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, content)
+
+	return status, nil
+}
+
+// renderStubBody renders a response body: objects and lists become JSON
+// with placeholders substituted and fake data tags generated, and strings
+// have their placeholders substituted.
+func (a *APITest) renderStubBody(body any) (string, error) {
+	switch v := body.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return a.replaceVars(v)
+	default:
+		rendered, err := a.renderJSON(marshalJSON(v))
+		if err != nil {
+			return "", err
+		}
+
+		var parsed any
+		if err := json.Unmarshal([]byte(rendered), &parsed); err != nil {
+			return "", fmt.Errorf("invalid response body: %w", err)
+		}
+		value, err := a.generateTemplate(parsed)
+		if err != nil {
+			return "", err
+		}
+		return marshalJSON(value), nil
+	}
+}
+
+// stubRequestValues exposes a request to response templates.
+func stubRequestValues(r *http.Request, body string, params map[string]string) map[string]any {
+	values := map[string]any{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"body":   body,
+	}
+
+	var parsed any
+	if json.Unmarshal([]byte(body), &parsed) == nil {
+		values["body"] = parsed
+	}
+
+	paramValues := map[string]any{}
+	for k, v := range params {
+		paramValues[k] = v
+	}
+	values["params"] = paramValues
+
+	query := map[string]any{}
+	for k, v := range r.URL.Query() {
+		query[k] = v[0]
+	}
+	values["query"] = query
+
+	headers := map[string]any{}
+	for k := range r.Header {
+		headers[k] = r.Header.Get(k)
+	}
+	values["headers"] = headers
+
+	return values
+}
+
+// match reports whether the stub accepts a request, returning the values of
+// its {name} path segments.
+func (stub *Stub) match(r *http.Request, body string) (map[string]string, bool) {
+	if stub.Request.Method != "" && !strings.EqualFold(stub.Request.Method, r.Method) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	if stub.pattern != nil {
+		match := stub.pattern.FindStringSubmatch(r.URL.Path)
+		if match == nil {
+			return nil, false
+		}
+		for i, name := range stub.pattern.SubexpNames() {
+			if name != "" {
+				params[name] = match[i]
+			}
+		}
+	}
+
+	query := r.URL.Query()
+	for k, expected := range stub.Request.Query {
+		if !query.Has(k) || !wildcardMatch(expected, query.Get(k)) {
+			return nil, false
+		}
+	}
+	for k, expected := range stub.Request.Headers {
+		if !wildcardMatch(expected, r.Header.Get(k)) {
+			return nil, false
+		}
+	}
+
+	switch expected := stub.Request.Body.(type) {
+	case nil:
+	case map[string]any:
+		var actual map[string]any
+		if json.Unmarshal([]byte(body), &actual) != nil || containsSubset(actual, expected) != nil {
+			return nil, false
+		}
+	case string:
+		if strings.TrimSpace(body) != strings.TrimSpace(expected) {
+			return nil, false
+		}
+	default:
+		var actual any
+		if json.Unmarshal([]byte(body), &actual) != nil || !reflect.DeepEqual(actual, expected) {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// wildcardMatch reports whether value matches pattern, where * matches any
+// run of characters.
+func wildcardMatch(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
+
+// compilePathPattern turns a stub path such as "/users/{id}/*" into a
+// regular expression capturing its named segments.
+func compilePathPattern(p string) (*regexp.Regexp, error) {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		switch {
+		case segment == "*":
+			segments[i] = `[^/]+`
+		case pathParamPattern.MatchString(segment):
+			segments[i] = `(?P<` + pathParamPattern.FindStringSubmatch(segment)[1] + `>[^/]+)`
+		default:
+			segments[i] = regexp.QuoteMeta(segment)
+		}
+	}
+	return regexp.Compile("^" + strings.Join(segments, "/") + "$")
+}
+
+// LoadStubs reads the stubs from every YAML and JSON file under dir, in file
+// name order. A file holds a single stub or a list of them.
+func LoadStubs(dir string) ([]*Stub, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+			if !d.IsDir() {
+				files = append(files, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stubs: %w", err)
+	}
+	slices.Sort(files)
+
+	var stubs []*Stub
+	for _, file := range files {
+		loaded, err := loadStubFile(file)
+		if err != nil {
+			return nil, err
+		}
+		stubs = append(stubs, loaded...)
+	}
+
+	return stubs, nil
+}
+
+func loadStubFile(file string) ([]*Stub, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stubs: %w", err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("invalid stub file %s: %w", file, err)
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	var stubs []*Stub
+	if node.Content[0].Kind == yaml.SequenceNode {
+		err = node.Content[0].Decode(&stubs)
+	} else {
+		var stub Stub
+		err = node.Content[0].Decode(&stub)
+		stubs = []*Stub{&stub}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid stub file %s: %w", file, err)
+	}
+
+	for i, stub := range stubs {
+		if stub.Name == "" {
+			stub.Name = fmt.Sprintf("%s#%d", filepath.Base(file), i+1)
+		}
+		stub.Request.Body = normalizeValue(stub.Request.Body)
+		stub.Response.Body = normalizeValue(stub.Response.Body)
+
+		if stub.Request.Path != "" {
+			if !strings.HasPrefix(stub.Request.Path, "/") {
+				return nil, fmt.Errorf("stub %s in %s: path %q must start with /", stub.Name, file, stub.Request.Path)
+			}
+			stub.pattern, err = compilePathPattern(stub.Request.Path)
+			if err != nil {
+				return nil, fmt.Errorf("stub %s in %s: %w", stub.Name, file, err)
+			}
+		}
+	}
+
+	return stubs, nil
+}
+
+// Routes describes the loaded stubs, one per line, for logging at startup.
+func (s *StubServer) Routes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	routes := make([]string, len(s.stubs))
+	for i, stub := range s.stubs {
+		method := stub.Request.Method
+		if method == "" {
+			method = "*"
+		}
+		p := stub.Request.Path
+		if p == "" {
+			p = "/*"
+		}
+		routes[i] = fmt.Sprintf("%s %s (%s)", strings.ToUpper(method), p, stub.Name)
+	}
+	return routes
+}
+
+func (s *StubServer) logf(format string, args ...any) {
+	if s.log != nil {
+		fmt.Fprintf(s.log, format, args...)
+	}
+}
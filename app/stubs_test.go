@@ -0,0 +1,155 @@
+package app
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const userStubs = `- name: get user
+  request:
+    method: GET
+    path: /users/{id}
+  response:
+    headers:
+      X-User: "${request.params.id}"
+    body:
+      id: "${request.params.id}"
+      tenant: "${tenant}"
+      email: "{email}"
+- name: admin search
+  request:
+    method: GET
+    path: /users
+    query: {role: admin}
+    headers: {Authorization: "Bearer *"}
+  response:
+    body: '[{"role": "admin"}]'
+`
+
+func TestStubServer(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "users.yaml"), []byte(userStubs), 0o644); err != nil {
+		t.Fatalf("Failed to write stubs: %v", err)
+	}
+	orders := `{"request": {"method": "POST", "path": "/orders", "body": {"type": "express"}}, "response": {"status": 201, "body": {"item": "${request.body.item}"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "orders.json"), []byte(orders), 0o644); err != nil {
+		t.Fatalf("Failed to write stubs: %v", err)
+	}
+
+	var log strings.Builder
+	server, err := NewStubServer(dir, Config{Variables: map[string]any{"tenant": "acme"}}, &log)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if server.Len() != 3 {
+		t.Fatalf("Expected 3 stubs, got %d", server.Len())
+	}
+
+	serve := func(method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("GET", "/users/42", "", nil)
+	if rec.Code != 200 || rec.Header().Get("X-User") != "42" || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a templated JSON response, got %d %v", rec.Code, rec.Header())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"id":"42"`) || !strings.Contains(body, `"tenant":"acme"`) || strings.Contains(body, "{email}") {
+		t.Errorf("Expected a templated body, got %s", body)
+	}
+
+	if rec := serve("GET", "/users?role=admin", "", map[string]string{"Authorization": "Bearer abc"}); rec.Body.String() != `[{"role": "admin"}]` {
+		t.Errorf("Expected the admin search stub, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve("GET", "/users?role=admin", "", nil); rec.Code != 404 {
+		t.Errorf("Expected 404 without the matching header, got %d", rec.Code)
+	}
+
+	rec = serve("POST", "/orders", `{"type": "express", "item": "book"}`, nil)
+	if rec.Code != 201 || rec.Body.String() != `{"item":"book"}` {
+		t.Errorf("Expected the order stub, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve("POST", "/orders", `{"type": "standard"}`, nil); rec.Code != 404 {
+		t.Errorf("Expected 404 for a body that does not match, got %d", rec.Code)
+	}
+
+	if !strings.Contains(log.String(), "POST /orders -> 201 (orders.json#1)\n") || !strings.Contains(log.String(), "GET /users?role=admin -> 404 (no matching stub)\n") {
+		t.Errorf("Expected requests to be logged, got:\n%s", log.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("- request: ["), 0o644); err != nil {
+		t.Fatalf("Failed to write stubs: %v", err)
+	}
+	if err := server.Reload(); err == nil {
+		t.Errorf("Expected an error for an invalid stub file")
+	}
+	if server.Len() != 3 {
+		t.Errorf("Expected the previous stubs to be kept, got %d", server.Len())
+	}
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern, value string
+		expected       bool
+	}{
+		{"admin", "admin", true},
+		{"admin", "admins", false},
+		{"Bearer *", "Bearer abc", true},
+		{"Bearer *", "Basic abc", false},
+		{"*.json", "a.json", true},
+		{"a*c*e", "abcde", true},
+		{"a*c*e", "abcd", false},
+	}
+
+	for _, test := range tests {
+		if got := wildcardMatch(test.pattern, test.value); got != test.expected {
+			t.Errorf("For %q and %q, expected %v, got %v", test.pattern, test.value, test.expected, got)
+		}
+	}
+}
+
+func TestStubMatchBody(t *testing.T) {
+	dir := t.TempDir()
+	stubs := `- request: {method: POST, path: /tags, body: [a, b]}
+- request: {method: POST, path: /count, body: 3}
+- request: {method: POST, path: /text, body: "hello"}
+`
+	if err := os.WriteFile(filepath.Join(dir, "bodies.yaml"), []byte(stubs), 0o644); err != nil {
+		t.Fatalf("Failed to write stubs: %v", err)
+	}
+	server, err := NewStubServer(dir, Config{}, &strings.Builder{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		path, body string
+		code       int
+	}{
+		{"/tags", `["a", "b"]`, 200},
+		{"/tags", `["a","b"]`, 200},
+		{"/tags", `["b", "a"]`, 404},
+		{"/tags", `[a b]`, 404},
+		{"/count", `3`, 200},
+		{"/count", `3.0`, 200},
+		{"/count", `4`, 404},
+		{"/text", ` hello `, 200},
+		{"/text", `"hello"`, 404},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest("POST", test.path, strings.NewReader(test.body)))
+		if rec.Code != test.code {
+			t.Errorf("For %s %s, expected %d, got %d", test.path, test.body, test.code, rec.Code)
+		}
+	}
+}
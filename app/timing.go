@@ -0,0 +1,192 @@
+package app
+
+import (
+	"cmp"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http/httptrace"
+	"slices"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// responseTimeVariables are the variables each request stores its timing
+// in, in milliseconds.
+var responseTimeVariables = []string{
+	"response.time_ms",
+	"response.dns_ms",
+	"response.connect_ms",
+	"response.tls_ms",
+	"response.ttfb_ms",
+}
+
+// RequestTiming is how long the phases of a request took. DNS, Connect and
+// TLS are zero when a connection was reused.
+type RequestTiming struct {
+	Scenario string
+	Method   string
+	URL      string
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	TTFB     time.Duration
+	Total    time.Duration
+}
+
+// Timings collects the timings of the requests sent during a run.
+type Timings struct {
+	mu       sync.Mutex
+	requests []RequestTiming
+}
+
+func (t *Timings) add(timing RequestTiming) {
+	t.mu.Lock()
+	t.requests = append(t.requests, timing)
+	t.mu.Unlock()
+}
+
+// Slowest returns up to n requests, slowest first.
+func (t *Timings) Slowest(n int) []RequestTiming {
+	t.mu.Lock()
+	requests := slices.Clone(t.requests)
+	t.mu.Unlock()
+
+	slices.SortStableFunc(requests, func(a, b RequestTiming) int {
+		return cmp.Compare(b.Total, a.Total)
+	})
+	return requests[:min(n, len(requests))]
+}
+
+// WriteSummary writes a table of the n slowest requests.
+func (t *Timings) WriteSummary(w io.Writer, n int) {
+	slowest := t.Slowest(n)
+	if len(slowest) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nSlowest requests:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  TOTAL\tDNS\tCONNECT\tTLS\tTTFB\tREQUEST\tSCENARIO")
+	for _, r := range slowest {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s %s\t%s\n",
+			formatMillis(r.Total), formatMillis(r.DNS), formatMillis(r.Connect), formatMillis(r.TLS), formatMillis(r.TTFB),
+			r.Method, r.URL, r.Scenario)
+	}
+	tw.Flush()
+}
+
+func formatMillis(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(millis(d), 'f', 1, 64) + "ms"
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// requestTrace records when the phases of a request happen. Dialing may
+// race several addresses, so the callbacks lock.
+type requestTrace struct {
+	mu                  sync.Mutex
+	start               time.Time
+	dnsStart, dnsDone   time.Time
+	connStart, connDone time.Time
+	tlsStart, tlsDone   time.Time
+	firstByte           time.Time
+}
+
+func (t *requestTrace) clientTrace() *httptrace.ClientTrace {
+	at := func(field *time.Time) {
+		t.mu.Lock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+		t.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { at(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { at(&t.dnsDone) },
+		ConnectStart:         func(string, string) { at(&t.connStart) },
+		ConnectDone:          func(string, string, error) { at(&t.connDone) },
+		TLSHandshakeStart:    func() { at(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { at(&t.tlsDone) },
+		GotFirstResponseByte: func() { at(&t.firstByte) },
+	}
+}
+
+// timing returns the phases of the request, which finished at end.
+func (t *requestTrace) timing(end time.Time) RequestTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	between := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	return RequestTiming{
+		DNS:     between(t.dnsStart, t.dnsDone),
+		Connect: between(t.connStart, t.connDone),
+		TLS:     between(t.tlsStart, t.tlsDone),
+		TTFB:    between(t.start, t.firstByte),
+		Total:   end.Sub(t.start),
+	}
+}
+
+// resetTiming clears the timing of the last request so each scenario starts
+// without one.
+func (a *APITest) resetTiming() {
+	a.timing = nil
+	for _, variable := range responseTimeVariables {
+		delete(a.store, variable)
+	}
+}
+
+// recordTiming stores the timing of the last request in the response.*_ms
+// variables and adds it to the run's timings.
+func (a *APITest) recordTiming(timing RequestTiming) {
+	a.timing = &timing
+	a.store["response.time_ms"] = millis(timing.Total)
+	a.store["response.dns_ms"] = millis(timing.DNS)
+	a.store["response.connect_ms"] = millis(timing.Connect)
+	a.store["response.tls_ms"] = millis(timing.TLS)
+	a.store["response.ttfb_ms"] = millis(timing.TTFB)
+
+	if a.timings != nil {
+		a.timings.add(timing)
+	}
+
+	if a.debug {
+		fmt.Printf("Response time: %s (dns %s, connect %s, tls %s, ttfb %s)\n",
+			formatMillis(timing.Total), formatMillis(timing.DNS), formatMillis(timing.Connect), formatMillis(timing.TLS), formatMillis(timing.TTFB))
+	}
+}
+
+func (a *APITest) theResponseTimeShouldBeBelow(limit string) error {
+	if a.timing == nil {
+		return fmt.Errorf("no request has been sent in this scenario")
+	}
+
+	threshold, err := time.ParseDuration(limit)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", limit, err)
+	}
+
+	t := a.timing
+	if t.Total >= threshold {
+		return fmt.Errorf("expected response time below %s but %s %s took %s (dns %s, connect %s, tls %s, ttfb %s)",
+			threshold, t.Method, t.URL, formatMillis(t.Total), formatMillis(t.DNS), formatMillis(t.Connect), formatMillis(t.TLS), formatMillis(t.TTFB))
+	}
+
+	if a.debug {
+		fmt.Printf("Response time %s is below %s\n", formatMillis(t.Total), threshold)
+	}
+
+	return nil
+}
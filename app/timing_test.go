@@ -0,0 +1,80 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	apiTest := NewAPITest(server.URL)
+	apiTest.timings = &Timings{}
+	apiTest.scenario = "Timing"
+
+	if err := apiTest.theResponseTimeShouldBeBelow("1s"); err == nil {
+		t.Errorf("Expected an error before any request")
+	}
+
+	if err := apiTest.sendRequest("GET", "/slow", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if apiTest.timing.Connect == 0 || apiTest.timing.TTFB < 30*time.Millisecond {
+		t.Errorf("Expected the connection and first byte to be timed, got %+v", apiTest.timing)
+	}
+
+	value, err := apiTest.evalExpr("response.time_ms")
+	if ms, ok := value.(float64); err != nil || !ok || ms < 30 {
+		t.Errorf("Expected response.time_ms to be at least 30, got %v (%v)", value, err)
+	}
+
+	err = apiTest.theResponseTimeShouldBeBelow("10ms")
+	if err == nil || !strings.Contains(err.Error(), "GET /slow took") {
+		t.Errorf("Expected the slow request in the error, got %v", err)
+	}
+	if err := apiTest.theResponseTimeShouldBeBelow("5s"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if err := apiTest.sendRequest("GET", "/fast", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	slowest := apiTest.timings.Slowest(1)
+	if len(slowest) != 1 || slowest[0].URL != "/slow" || slowest[0].Scenario != "Timing" {
+		t.Errorf("Expected /slow to be the slowest request, got %+v", slowest)
+	}
+
+	var buf bytes.Buffer
+	apiTest.timings.WriteSummary(&buf, 5)
+	summary := buf.String()
+	if !strings.Contains(summary, "TTFB") || strings.Index(summary, "GET /slow") > strings.Index(summary, "GET /fast") {
+		t.Errorf("Expected a table of requests, slowest first, got:\n%s", summary)
+	}
+
+	apiTest.resetTiming()
+	if _, ok := apiTest.store["response.time_ms"]; ok || apiTest.timing != nil {
+		t.Errorf("Expected the timing to be cleared, got %v", apiTest.store["response.time_ms"])
+	}
+
+	// A failed request does not keep the previous request's timing.
+	if err := apiTest.sendRequest("GET", "/fast", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	apiTest.baseURL = "http://127.0.0.1:0"
+	if err := apiTest.sendRequest("GET", "/fast", ""); err == nil {
+		t.Fatalf("Expected the request to fail")
+	}
+	if _, ok := apiTest.store["response.time_ms"]; ok || apiTest.timing != nil {
+		t.Errorf("Expected no timing after a failed request, got %v", apiTest.store["response.time_ms"])
+	}
+}
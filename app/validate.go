@@ -0,0 +1,318 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxSchemaErrors bounds how many mismatches are reported for one response.
+const maxSchemaErrors = 10
+
+var (
+	emailFormat = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	uuidFormat  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+func (a *APITest) theResponseShouldMatchSchema(ref string) error {
+	ref, err := a.replaceVars(ref)
+	if err != nil {
+		return err
+	}
+
+	schema, root, err := loadSchema(ref)
+	if err != nil {
+		return err
+	}
+
+	var actual any
+	if err := json.Unmarshal([]byte(a.responseBody), &actual); err != nil {
+		return fmt.Errorf("invalid response JSON: %w", err)
+	}
+
+	validator := &schemaValidator{root: root}
+	errs := validator.validate(actual, schema, "", 0)
+	if len(errs) > maxSchemaErrors {
+		errs = append(errs[:maxSchemaErrors], fmt.Sprintf("and %d more", len(errs)-maxSchemaErrors))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("response does not match schema %s:\n  %s", ref, strings.Join(errs, "\n  "))
+	}
+
+	if a.debug {
+		fmt.Printf("Response matches schema %s\n", ref)
+	}
+
+	return nil
+}
+
+// schemaValidator checks values against the JSON Schema subset used by
+// OpenAPI documents: types, required and additional properties, items,
+// enums, ranges, lengths, patterns, common formats and composition.
+type schemaValidator struct {
+	root map[string]any
+}
+
+// validate returns a description of every mismatch, prefixed with the
+// gjson-style path of the offending value.
+func (v *schemaValidator) validate(value any, schema map[string]any, path string, depth int) []string {
+	if depth > schemaDepthLimit {
+		return nil
+	}
+
+	schema, err := resolveRef(v.root, schema)
+	if err != nil {
+		return []string{schemaError(path, err.Error())}
+	}
+
+	if value == nil && (schema["nullable"] == true || schemaAllows(schema, "null")) {
+		return nil
+	}
+
+	var errs []string
+
+	for _, part := range schemaList(schema["allOf"]) {
+		errs = append(errs, v.validate(value, part, path, depth+1)...)
+	}
+	if options := schemaList(schema["anyOf"]); len(options) > 0 && v.matching(value, options, path, depth) == 0 {
+		errs = append(errs, schemaError(path, "does not match any of the anyOf schemas"))
+	}
+	if options := schemaList(schema["oneOf"]); len(options) > 0 {
+		if n := v.matching(value, options, path, depth); n != 1 {
+			errs = append(errs, schemaError(path, fmt.Sprintf("matches %d of the oneOf schemas, expected exactly 1", n)))
+		}
+	}
+
+	if expected, ok := schema["const"]; ok && !reflect.DeepEqual(value, expected) {
+		errs = append(errs, schemaError(path, fmt.Sprintf("expected %s, got %s", marshalJSON(expected), marshalJSON(value))))
+	}
+	if values, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(values, func(e any) bool { return reflect.DeepEqual(value, e) }) {
+		errs = append(errs, schemaError(path, fmt.Sprintf("%s is not one of %s", marshalJSON(value), marshalJSON(values))))
+	}
+
+	if _, typed := schema["type"]; typed && !schemaAllows(schema, jsonType(value)) &&
+		!(jsonType(value) == "integer" && schemaAllows(schema, "number")) {
+		return append(errs, schemaError(path, fmt.Sprintf("expected %s, got %s", describeTypes(schema["type"]), jsonType(value))))
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		errs = append(errs, v.validateObject(val, schema, path, depth)...)
+	case []any:
+		errs = append(errs, v.validateArray(val, schema, path, depth)...)
+	case string:
+		errs = append(errs, validateString(val, schema, path)...)
+	case float64:
+		errs = append(errs, validateNumber(val, schema, path)...)
+	}
+
+	return errs
+}
+
+// matching counts the options value matches.
+func (v *schemaValidator) matching(value any, options []map[string]any, path string, depth int) int {
+	n := 0
+	for _, option := range options {
+		if len(v.validate(value, option, path, depth+1)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (v *schemaValidator) validateObject(obj map[string]any, schema map[string]any, path string, depth int) []string {
+	var errs []string
+
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if key, ok := name.(string); ok {
+			if _, exists := obj[key]; !exists {
+				errs = append(errs, schemaError(schemaPath(path, key), "is required"))
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		if propSchema, ok := properties[key].(map[string]any); ok {
+			errs = append(errs, v.validate(obj[key], propSchema, schemaPath(path, key), depth+1)...)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				errs = append(errs, schemaError(schemaPath(path, key), "is not allowed"))
+			}
+		case map[string]any:
+			errs = append(errs, v.validate(obj[key], additional, schemaPath(path, key), depth+1)...)
+		}
+	}
+
+	return errs
+}
+
+func (v *schemaValidator) validateArray(arr []any, schema map[string]any, path string, depth int) []string {
+	var errs []string
+
+	if n, ok := toNumber(schema["minItems"]); ok && float64(len(arr)) < n {
+		errs = append(errs, schemaError(path, fmt.Sprintf("has %d items, expected at least %v", len(arr), n)))
+	}
+	if n, ok := toNumber(schema["maxItems"]); ok && float64(len(arr)) > n {
+		errs = append(errs, schemaError(path, fmt.Sprintf("has %d items, expected at most %v", len(arr), n)))
+	}
+
+	if items, ok := schema["items"].(map[string]any); ok {
+		for i, item := range arr {
+			errs = append(errs, v.validate(item, items, schemaPath(path, fmt.Sprint(i)), depth+1)...)
+		}
+	}
+
+	return errs
+}
+
+func validateString(s string, schema map[string]any, path string) []string {
+	var errs []string
+
+	length := len([]rune(s))
+	if n, ok := toNumber(schema["minLength"]); ok && float64(length) < n {
+		errs = append(errs, schemaError(path, fmt.Sprintf("is %d characters long, expected at least %v", length, n)))
+	}
+	if n, ok := toNumber(schema["maxLength"]); ok && float64(length) > n {
+		errs = append(errs, schemaError(path, fmt.Sprintf("is %d characters long, expected at most %v", length, n)))
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, schemaError(path, fmt.Sprintf("invalid pattern %q in schema: %v", pattern, err)))
+		} else if !re.MatchString(s) {
+			errs = append(errs, schemaError(path, fmt.Sprintf("%q does not match pattern %q", s, pattern)))
+		}
+	}
+
+	format, _ := schema["format"].(string)
+	valid := true
+	switch format {
+	case "email":
+		valid = emailFormat.MatchString(s)
+	case "uuid":
+		valid = uuidFormat.MatchString(s)
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		valid = err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, s)
+		valid = err == nil
+	}
+	if !valid {
+		errs = append(errs, schemaError(path, fmt.Sprintf("%q is not a valid %s", s, format)))
+	}
+
+	return errs
+}
+
+func validateNumber(n float64, schema map[string]any, path string) []string {
+	var errs []string
+
+	// OpenAPI 3.0 marks exclusive bounds with booleans, 3.1 with numbers.
+	if lo, ok := toNumber(schema["minimum"]); ok {
+		if schema["exclusiveMinimum"] == true && n <= lo {
+			errs = append(errs, schemaError(path, fmt.Sprintf("%v must be greater than %v", n, lo)))
+		} else if n < lo {
+			errs = append(errs, schemaError(path, fmt.Sprintf("%v must be at least %v", n, lo)))
+		}
+	}
+	if lo, ok := toNumber(schema["exclusiveMinimum"]); ok && n <= lo {
+		errs = append(errs, schemaError(path, fmt.Sprintf("%v must be greater than %v", n, lo)))
+	}
+	if hi, ok := toNumber(schema["maximum"]); ok {
+		if schema["exclusiveMaximum"] == true && n >= hi {
+			errs = append(errs, schemaError(path, fmt.Sprintf("%v must be less than %v", n, hi)))
+		} else if n > hi {
+			errs = append(errs, schemaError(path, fmt.Sprintf("%v must be at most %v", n, hi)))
+		}
+	}
+	if hi, ok := toNumber(schema["exclusiveMaximum"]); ok && n >= hi {
+		errs = append(errs, schemaError(path, fmt.Sprintf("%v must be less than %v", n, hi)))
+	}
+
+	return errs
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value.
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// schemaAllows reports whether the schema's type, a string or a list,
+// includes name.
+func schemaAllows(schema map[string]any, name string) bool {
+	switch t := schema["type"].(type) {
+	case string:
+		return t == name
+	case []any:
+		return slices.Contains(t, any(name))
+	}
+	return false
+}
+
+func describeTypes(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func schemaList(value any) []map[string]any {
+	list, _ := value.([]any)
+	var schemas []map[string]any
+	for _, item := range list {
+		if schema, ok := item.(map[string]any); ok {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas
+}
+
+func schemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func schemaError(path, msg string) string {
+	if path == "" {
+		return "response " + msg
+	}
+	return path + " " + msg
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTheResponseShouldMatchSchema(t *testing.T) {
+	dir := t.TempDir()
+	spec := `
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, email]
+      additionalProperties: false
+      properties:
+        id: {type: integer, minimum: 1}
+        email: {type: string, format: email}
+        role: {type: string, enum: [admin, member]}
+        nickname: {type: string, nullable: true, maxLength: 5}
+        tags: {type: array, items: {type: string}, maxItems: 2}
+        pet:
+          oneOf:
+            - {type: object, required: [meows], properties: {meows: {type: boolean}}}
+            - {type: object, required: [barks], properties: {barks: {type: boolean}}}
+`
+	path := filepath.Join(dir, "api.yaml")
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	ref := path + "#/components/schemas/User"
+
+	apiTest := NewAPITest("")
+	apiTest.responseBody = `{"id": 7, "email": "a@b.co", "role": "admin", "nickname": null, "tags": ["x"], "pet": {"barks": true}}`
+	if err := apiTest.theResponseShouldMatchSchema(ref); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	apiTest.responseBody = `{"id": 1.5, "email": "nope", "role": "owner", "nickname": "toolong", "tags": ["x", "y", 3], "pet": {}, "extra": 1}`
+	err := apiTest.theResponseShouldMatchSchema(ref)
+	if err == nil {
+		t.Fatal("Expected an error for a mismatching response")
+	}
+	for _, expected := range []string{
+		`id expected integer, got number`,
+		`email "nope" is not a valid email`,
+		`role "owner" is not one of ["admin","member"]`,
+		`nickname is 7 characters long, expected at most 5`,
+		`tags has 3 items, expected at most 2`,
+		`tags.2 expected string, got integer`,
+		`pet matches 0 of the oneOf schemas, expected exactly 1`,
+		`extra is not allowed`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got %v", expected, err)
+		}
+	}
+
+	apiTest.responseBody = `{"email": "a@b.co"}`
+	if err := apiTest.theResponseShouldMatchSchema(ref); err == nil || !strings.Contains(err.Error(), "id is required") {
+		t.Errorf("Expected a missing property error, got %v", err)
+	}
+
+	if err := apiTest.theResponseShouldMatchSchema(path + "#/components/schemas/Missing"); err == nil {
+		t.Error("Expected an error for a missing schema reference")
+	}
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func (a *APITest) iLoadVariablesFrom(path string) error {
+	path, err := a.replaceVars(path)
+	if err != nil {
+		return err
+	}
+
+	vars, err := LoadVariables(path)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range vars {
+		a.store[k] = v
+	}
+
+	if a.debug {
+		fmt.Printf("Loaded %d variables from %s\n", len(vars), path)
+	}
+
+	return nil
+}
+
+func (a *APITest) iLoadEnvironmentVariables(names string) error {
+	vars, err := LoadEnvironmentVariables(strings.Split(names, ","))
+	if err != nil {
+		return err
+	}
+
+	for k, v := range vars {
+		a.store[k] = v
+	}
+
+	if a.debug {
+		fmt.Printf("Loaded environment variables: %s\n", names)
+	}
+
+	return nil
+}
+
+func (a *APITest) iStoreTheContentsOfAs(path, variable string) error {
+	path, err := a.replaceVars(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		var value any
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("invalid contents in %s: %w", path, err)
+		}
+		a.store[variable] = normalizeValue(value)
+	default:
+		a.store[variable] = string(data)
+	}
+
+	if a.debug {
+		fmt.Printf("Stored contents of %s as %s: %v\n", path, variable, a.store[variable])
+	}
+
+	return nil
+}
+
+func (a *APITest) iStoreTheFollowingAs(variable, content string) error {
+	content, err := a.replaceVars(content)
+	if err != nil {
+		return err
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(content), &value); err == nil {
+		a.store[variable] = value
+	} else {
+		a.store[variable] = content
+	}
+
+	if a.debug {
+		fmt.Printf("Stored docstring as %s: %v\n", variable, a.store[variable])
+	}
+
+	return nil
+}
+
+// LoadVariables reads variables from a .env, JSON or YAML file. JSON and
+// YAML files must contain an object whose values keep their types.
+func LoadVariables(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read variables: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".json" || ext == ".yaml" || ext == ".yml":
+		var vars map[string]any
+		if err := yaml.Unmarshal(data, &vars); err != nil {
+			return nil, fmt.Errorf("invalid variables in %s: %w", path, err)
+		}
+		for k, v := range vars {
+			vars[k] = normalizeValue(v)
+		}
+		return vars, nil
+	case ext == ".env" || strings.HasPrefix(filepath.Base(path), ".env"):
+		return parseDotEnv(string(data))
+	default:
+		return nil, fmt.Errorf("unsupported variables file type %q", ext)
+	}
+}
+
+// LoadEnvironmentVariables copies the named environment variables, failing
+// if any of them is not set.
+func LoadEnvironmentVariables(names []string) (map[string]any, error) {
+	vars := map[string]any{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+func parseDotEnv(content string) (map[string]any, error) {
+	vars := map[string]any{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid .env line %d: %s", n, line)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[strings.TrimSpace(key)] = value
+	}
+	return vars, scanner.Err()
+}
+
+// normalizeValue converts decoded YAML into the same types encoding/json
+// produces, so values behave identically whichever format they came from.
+func normalizeValue(val any) any {
+	switch v := val.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalizeValue(item)
+		}
+		return v
+	case map[any]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			result[fmt.Sprintf("%v", k)] = normalizeValue(item)
+		}
+		return result
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeValue(item)
+		}
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	default:
+		return v
+	}
+}
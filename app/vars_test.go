@@ -0,0 +1,115 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestILoadVariablesFrom(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"users.yaml": "admin:\n  email: admin@example.com\n  roles: [owner]\nlimit: 10\n",
+		"users.json": `{"token": "abc", "enabled": true}`,
+		".env":       "# comment\nexport API_HOST=localhost\nAPI_KEY=\"quoted value\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	for name := range files {
+		if err := apiTest.iLoadVariablesFrom(filepath.Join(dir, name)); err != nil {
+			t.Errorf("For %s, expected no error, got %v", name, err)
+		}
+	}
+
+	expected := map[string]any{
+		"admin":    map[string]any{"email": "admin@example.com", "roles": []any{"owner"}},
+		"limit":    float64(10),
+		"token":    "abc",
+		"enabled":  true,
+		"API_HOST": "localhost",
+		"API_KEY":  "quoted value",
+	}
+	for k, v := range expected {
+		if !reflect.DeepEqual(apiTest.store[k], v) {
+			t.Errorf("Expected %s to be %v, got %v", k, v, apiTest.store[k])
+		}
+	}
+
+	if err := apiTest.iLoadVariablesFrom(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+}
+
+func TestILoadEnvironmentVariables(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	t.Setenv("RBDD_TEST_ONE", "1")
+	t.Setenv("RBDD_TEST_TWO", "two")
+
+	if err := apiTest.iLoadEnvironmentVariables("RBDD_TEST_ONE, RBDD_TEST_TWO"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if apiTest.store["RBDD_TEST_ONE"] != "1" || apiTest.store["RBDD_TEST_TWO"] != "two" {
+		t.Errorf("Expected environment variables to be stored, got %v and %v",
+			apiTest.store["RBDD_TEST_ONE"], apiTest.store["RBDD_TEST_TWO"])
+	}
+
+	if err := apiTest.iLoadEnvironmentVariables("RBDD_TEST_UNSET"); err == nil {
+		t.Error("Expected error for unset environment variable, got nil")
+	}
+}
+
+func TestIStoreTheContentsOfAs(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	dir := t.TempDir()
+
+	order := filepath.Join(dir, "order.json")
+	if err := os.WriteFile(order, []byte(`{"items": [{"sku": "A1", "qty": 2}]}`), 0o644); err != nil {
+		t.Fatalf("Failed to write order: %v", err)
+	}
+	note := filepath.Join(dir, "note.txt")
+	if err := os.WriteFile(note, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	if err := apiTest.iStoreTheContentsOfAs(order, "order"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := map[string]any{"items": []any{map[string]any{"sku": "A1", "qty": float64(2)}}}
+	if !reflect.DeepEqual(apiTest.store["order"], expected) {
+		t.Errorf("Expected %v, got %v", expected, apiTest.store["order"])
+	}
+
+	if err := apiTest.iStoreTheContentsOfAs(note, "note"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if apiTest.store["note"] != "hello" {
+		t.Errorf("Expected 'hello', got %v", apiTest.store["note"])
+	}
+}
+
+func TestIStoreTheFollowingAs(t *testing.T) {
+	apiTest := NewAPITest("https://example.com")
+	apiTest.store["sku"] = "A1"
+
+	if err := apiTest.iStoreTheFollowingAs("item", `{"sku": "${sku}", "qty": 1}`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := map[string]any{"sku": "A1", "qty": float64(1)}
+	if !reflect.DeepEqual(apiTest.store["item"], expected) {
+		t.Errorf("Expected %v, got %v", expected, apiTest.store["item"])
+	}
+
+	if err := apiTest.iStoreTheFollowingAs("text", "not json"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if apiTest.store["text"] != "not json" {
+		t.Errorf("Expected 'not json', got %v", apiTest.store["text"])
+	}
+}
@@ -0,0 +1,245 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// defaultSignatureHeader carries webhook signatures unless configured
+// otherwise.
+const defaultSignatureHeader = "X-Signature"
+
+// webhookListener receives the webhooks an API sends during a scenario.
+type webhookListener struct {
+	server *http.Server
+	url    string
+	// variables are the store variables holding url, removed on reset.
+	variables []string
+	mu        sync.Mutex
+	received  []*webhook
+	arrived   chan struct{}
+}
+
+type webhook struct {
+	path     string
+	headers  http.Header
+	body     string
+	consumed bool
+}
+
+func newWebhookListener() (*webhookListener, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start webhook listener: %w", err)
+	}
+
+	l := &webhookListener{
+		url:     "http://" + listener.Addr().String() + "/webhooks",
+		arrived: make(chan struct{}),
+	}
+	l.server = &http.Server{Handler: l}
+	go l.server.Serve(listener)
+
+	return l, nil
+}
+
+func (l *webhookListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	l.mu.Lock()
+	l.received = append(l.received, &webhook{path: r.URL.Path, headers: r.Header.Clone(), body: string(body)})
+	// Wake every waiting step by closing the channel, then replace it for
+	// the next webhook.
+	close(l.arrived)
+	l.arrived = make(chan struct{})
+	l.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resetWebhooks stops the webhook listener so each scenario starts without
+// one.
+func (a *APITest) resetWebhooks() {
+	if a.webhooks != nil {
+		a.webhooks.server.Close()
+		for _, variable := range a.webhooks.variables {
+			delete(a.store, variable)
+		}
+		a.webhooks = nil
+	}
+	a.webhook = ""
+}
+
+func (a *APITest) iListenForWebhooksOn(variable string) error {
+	variable = webhookVariable(variable)
+
+	if a.webhooks == nil {
+		l, err := newWebhookListener()
+		if err != nil {
+			return err
+		}
+		a.webhooks = l
+	}
+	a.store[variable] = a.webhooks.url
+	if !slices.Contains(a.webhooks.variables, variable) {
+		a.webhooks.variables = append(a.webhooks.variables, variable)
+	}
+
+	if a.debug {
+		fmt.Printf("Listening for webhooks on %s, stored as %s\n", a.webhooks.url, variable)
+	}
+
+	return nil
+}
+
+// webhookVariable accepts the variable for the listener URL either as a
+// name or as a ${name} reference.
+func webhookVariable(variable string) string {
+	variable = strings.TrimSpace(variable)
+	if strings.HasPrefix(variable, "${") && strings.HasSuffix(variable, "}") {
+		return variable[2 : len(variable)-1]
+	}
+	return variable
+}
+
+func (a *APITest) iShouldReceiveAWebhookWithin(timeout string) error {
+	return a.iShouldReceiveAWebhookWithinWithJSONContaining(timeout, "")
+}
+
+func (a *APITest) iShouldReceiveAWebhookWithinWithJSONContaining(timeout, expected string) error {
+	if a.webhooks == nil {
+		return fmt.Errorf(`no webhook listener in this scenario, add: Given I listen for webhooks on "webhook_url"`)
+	}
+
+	wait, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q: %w", timeout, err)
+	}
+
+	var subset map[string]any
+	if expected != "" {
+		templated, err := a.replaceVars(expected)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(templated), &subset); err != nil {
+			return fmt.Errorf("invalid expected JSON: %w", err)
+		}
+	}
+
+	deadline := time.After(wait)
+	for {
+		a.webhooks.mu.Lock()
+		hook, mismatches := a.matchWebhook(subset)
+		arrived := a.webhooks.arrived
+		a.webhooks.mu.Unlock()
+
+		if hook != nil {
+			a.webhook = hook.body
+			if a.debug {
+				fmt.Printf("Received webhook on %s: %s\n", hook.path, hook.body)
+			}
+			return nil
+		}
+
+		select {
+		case <-arrived:
+		case <-deadline:
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "no matching webhook received within %s", wait)
+			for _, mismatch := range mismatches {
+				fmt.Fprintf(&sb, "\n  not matching: %s", mismatch)
+			}
+			return errors.New(sb.String())
+		}
+	}
+}
+
+// matchWebhook consumes the first unconsumed webhook with a valid signature
+// whose JSON body contains subset, describing why the others did not match.
+// The listener's lock must be held.
+func (a *APITest) matchWebhook(subset map[string]any) (*webhook, []string) {
+	var mismatches []string
+	for _, hook := range a.webhooks.received {
+		if hook.consumed {
+			continue
+		}
+		if err := a.verifySignature(hook); err != nil {
+			mismatches = append(mismatches, err.Error())
+			continue
+		}
+		if subset != nil {
+			var actual map[string]any
+			if err := json.Unmarshal([]byte(hook.body), &actual); err != nil {
+				mismatches = append(mismatches, fmt.Sprintf("body is not a JSON object: %s", hook.body))
+				continue
+			}
+			if err := containsSubset(actual, subset); err != nil {
+				mismatches = append(mismatches, err.Error())
+				continue
+			}
+		}
+		hook.consumed = true
+		return hook, nil
+	}
+	return nil, mismatches
+}
+
+// verifySignature checks a webhook's HMAC-SHA256 signature of its body when
+// a webhook secret is configured. The signature is hex encoded, optionally
+// prefixed with "sha256=".
+func (a *APITest) verifySignature(hook *webhook) error {
+	if a.webhookSecret == "" {
+		return nil
+	}
+
+	header := a.webhookSignatureHeader
+	if header == "" {
+		header = defaultSignatureHeader
+	}
+
+	signature := strings.TrimPrefix(hook.headers.Get(header), "sha256=")
+	if signature == "" {
+		return fmt.Errorf("webhook to %s has no %s signature header", hook.path, header)
+	}
+
+	mac := hmac.New(sha256.New, []byte(a.webhookSecret))
+	mac.Write([]byte(hook.body))
+	actual, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(actual, mac.Sum(nil)) {
+		return fmt.Errorf("webhook to %s has an invalid %s signature", hook.path, header)
+	}
+
+	return nil
+}
+
+func (a *APITest) iStoreTheWebhookPropertyAs(property, variable string) error {
+	if a.webhook == "" {
+		return fmt.Errorf("no webhook has been received in this scenario")
+	}
+
+	value := gjson.Get(a.webhook, property)
+	if !value.Exists() {
+		return fmt.Errorf("property %s not found in webhook %s", property, a.webhook)
+	}
+	a.store[variable] = storedValue(value)
+
+	if a.debug {
+		fmt.Printf("Stored webhook property %s as %s: %v\n", property, variable, a.store[variable])
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhooks(t *testing.T) {
+	apiTest := NewAPITest("")
+	apiTest.webhookSecret = "shh"
+	defer apiTest.resetWebhooks()
+
+	if err := apiTest.iShouldReceiveAWebhookWithin("10ms"); err == nil {
+		t.Errorf("Expected an error without a webhook listener")
+	}
+
+	if err := apiTest.iListenForWebhooksOn("${webhook_url}"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	url, ok := apiTest.store["webhook_url"].(string)
+	if !ok || !strings.HasPrefix(url, "http://127.0.0.1:") {
+		t.Fatalf("Expected the listener URL to be stored, got %v", apiTest.store["webhook_url"])
+	}
+
+	send := func(body, signature string) {
+		req, _ := http.NewRequest("POST", url, bytes.NewBufferString(body))
+		if signature != "" {
+			req.Header.Set("X-Signature", signature)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send webhook: %v", err)
+		}
+		resp.Body.Close()
+	}
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("shh"))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	send(`{"event": "order.paid", "data": {"id": 5}}`, "sha256=00")
+	err := apiTest.iShouldReceiveAWebhookWithinWithJSONContaining("50ms", `{"event": "order.paid"}`)
+	if err == nil || !strings.Contains(err.Error(), "invalid X-Signature signature") {
+		t.Errorf("Expected the unsigned webhook to be rejected, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		body := `{"event": "order.paid", "data": {"id": 7}}`
+		send(body, sign(body))
+	}()
+	if err := apiTest.iShouldReceiveAWebhookWithinWithJSONContaining("2s", `{"event": "order.paid"}`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := apiTest.iStoreTheWebhookPropertyAs("data.id", "order_id"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if apiTest.store["order_id"] != float64(7) {
+		t.Errorf("Expected order_id to be 7, got %v", apiTest.store["order_id"])
+	}
+
+	if err := apiTest.iShouldReceiveAWebhookWithin("20ms"); err == nil {
+		t.Errorf("Expected a matched webhook not to be received twice")
+	}
+
+	apiTest.resetWebhooks()
+	if _, ok := apiTest.store["webhook_url"]; ok {
+		t.Errorf("Expected the webhook URL variable to be removed on reset, got %v", apiTest.store["webhook_url"])
+	}
+}
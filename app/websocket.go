@@ -0,0 +1,312 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/tidwall/gjson"
+)
+
+// webSocketConn is the WebSocket connection of a scenario, with the
+// messages received on it so far.
+type webSocketConn struct {
+	conn     *websocket.Conn
+	mu       sync.Mutex
+	received []*webSocketMessage
+	arrived  chan struct{}
+	// closeCode is set once the connection has closed, to the code the
+	// server sent or 1006 when it closed without one.
+	closeCode   int
+	closeReason string
+	closed      bool
+}
+
+type webSocketMessage struct {
+	data     string
+	consumed bool
+}
+
+// read receives messages until the connection closes, waking waiting steps
+// on every message and on close.
+func (c *webSocketConn) read() {
+	for {
+		_, data, err := c.conn.ReadMessage()
+
+		c.mu.Lock()
+		if err != nil {
+			c.closed = true
+			c.closeCode = websocket.CloseAbnormalClosure
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				c.closeCode = closeErr.Code
+				c.closeReason = closeErr.Text
+			}
+		} else {
+			c.received = append(c.received, &webSocketMessage{data: string(data)})
+		}
+		close(c.arrived)
+		c.arrived = make(chan struct{})
+		c.mu.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// resetWebSocket closes the WebSocket connection so each scenario starts
+// without one.
+func (a *APITest) resetWebSocket() {
+	if a.webSocket != nil {
+		a.webSocket.conn.Close()
+		a.webSocket = nil
+	}
+	a.webSocketMessage = ""
+}
+
+// webSocketURL resolves an endpoint against the base URL, switching http to
+// ws and https to wss.
+func (a *APITest) webSocketURL(endpoint string) (string, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = a.baseURL + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid WebSocket URL %q: %w", endpoint, err)
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+	return u.String(), nil
+}
+
+func (a *APITest) iConnectToTheWebSocket(endpoint string) error {
+	endpoint, err := a.replaceVars(endpoint)
+	if err != nil {
+		return err
+	}
+	target, err := a.webSocketURL(endpoint)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	for k, v := range a.headers {
+		if strings.EqualFold(k, "Content-Type") {
+			continue
+		}
+		value, err := a.replaceVars(v)
+		if err != nil {
+			return err
+		}
+		header.Set(k, value)
+	}
+
+	a.resetWebSocket()
+	conn, resp, err := websocket.DefaultDialer.Dial(target, header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("failed to connect to WebSocket %s: %w (status %d)", target, err, resp.StatusCode)
+		}
+		return fmt.Errorf("failed to connect to WebSocket %s: %w", target, err)
+	}
+	a.webSocket = &webSocketConn{conn: conn, arrived: make(chan struct{})}
+	go a.webSocket.read()
+
+	if a.debug {
+		fmt.Printf("Connected to WebSocket %s\n", target)
+	}
+
+	return nil
+}
+
+func (a *APITest) iSendTheWebSocketMessage(message string) error {
+	if a.webSocket == nil {
+		return fmt.Errorf(`no WebSocket connection in this scenario, add: When I connect to the WebSocket "/ws"`)
+	}
+
+	var err error
+	if isJSONPayload(message) {
+		message, err = a.renderJSON(message)
+	} else {
+		message, err = a.replaceVars(message)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := a.webSocket.conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send WebSocket message: %w", err)
+	}
+
+	if a.debug {
+		fmt.Printf("Sent WebSocket message: %s\n", message)
+	}
+
+	return nil
+}
+
+func (a *APITest) iShouldReceiveAWebSocketMessageWithin(timeout string) error {
+	return a.iShouldReceiveAWebSocketMessageWhereWithin("", timeout)
+}
+
+// iShouldReceiveAWebSocketMessageWhereWithin waits for a message matching a
+// gjson condition such as type == "order.created" or data.total > 10. Each
+// message matches one step; messages that do not match stay available.
+func (a *APITest) iShouldReceiveAWebSocketMessageWhereWithin(condition, timeout string) error {
+	if a.webSocket == nil {
+		return fmt.Errorf(`no WebSocket connection in this scenario, add: When I connect to the WebSocket "/ws"`)
+	}
+
+	wait, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q: %w", timeout, err)
+	}
+	condition, err = a.replaceVars(condition)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.After(wait)
+	for {
+		a.webSocket.mu.Lock()
+		message := a.webSocket.match(condition)
+		arrived := a.webSocket.arrived
+		closed, closeCode := a.webSocket.closed, a.webSocket.closeCode
+		var unmatched []string
+		for _, m := range a.webSocket.received {
+			if !m.consumed {
+				unmatched = append(unmatched, m.data)
+			}
+		}
+		a.webSocket.mu.Unlock()
+
+		if message != nil {
+			a.webSocketMessage = message.data
+			if a.debug {
+				fmt.Printf("Received WebSocket message: %s\n", message.data)
+			}
+			return nil
+		}
+
+		// Nothing more arrives once the connection has closed.
+		if !closed {
+			select {
+			case <-arrived:
+				continue
+			case <-deadline:
+			}
+		}
+
+		var sb strings.Builder
+		if condition == "" {
+			sb.WriteString("no WebSocket message received")
+		} else {
+			fmt.Fprintf(&sb, "no WebSocket message where %s received", condition)
+		}
+		if closed {
+			fmt.Fprintf(&sb, " before the connection closed with code %d", closeCode)
+		} else {
+			fmt.Fprintf(&sb, " within %s", wait)
+		}
+		for _, data := range unmatched {
+			fmt.Fprintf(&sb, "\n  not matching: %s", data)
+		}
+		return errors.New(sb.String())
+	}
+}
+
+// match consumes the first unconsumed message matching condition. The lock
+// must be held.
+func (c *webSocketConn) match(condition string) *webSocketMessage {
+	for _, m := range c.received {
+		if m.consumed {
+			continue
+		}
+		if condition != "" && !gjson.Get("["+m.data+"]", "#("+condition+")").Exists() {
+			continue
+		}
+		m.consumed = true
+		return m
+	}
+	return nil
+}
+
+func (a *APITest) iStoreTheWebSocketMessagePropertyAs(property, variable string) error {
+	if a.webSocketMessage == "" {
+		return fmt.Errorf("no WebSocket message has been received in this scenario")
+	}
+
+	value := gjson.Get(a.webSocketMessage, property)
+	if !value.Exists() {
+		return fmt.Errorf("property %s not found in WebSocket message %s", property, a.webSocketMessage)
+	}
+	a.store[variable] = storedValue(value)
+
+	if a.debug {
+		fmt.Printf("Stored WebSocket message property %s as %s: %v\n", property, variable, a.store[variable])
+	}
+
+	return nil
+}
+
+func (a *APITest) iCloseTheWebSocket() error {
+	if a.webSocket == nil {
+		return fmt.Errorf("no WebSocket connection in this scenario")
+	}
+
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if err := a.webSocket.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second)); err != nil {
+		return fmt.Errorf("failed to close WebSocket: %w", err)
+	}
+
+	if a.debug {
+		fmt.Printf("Closed WebSocket\n")
+	}
+
+	return nil
+}
+
+func (a *APITest) theWebSocketShouldCloseWithCodeWithin(code int, timeout string) error {
+	if a.webSocket == nil {
+		return fmt.Errorf("no WebSocket connection in this scenario")
+	}
+
+	wait, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q: %w", timeout, err)
+	}
+
+	deadline := time.After(wait)
+	for {
+		a.webSocket.mu.Lock()
+		closed, closeCode, reason := a.webSocket.closed, a.webSocket.closeCode, a.webSocket.closeReason
+		arrived := a.webSocket.arrived
+		a.webSocket.mu.Unlock()
+
+		if closed {
+			if closeCode != code {
+				return fmt.Errorf("expected the WebSocket to close with code %d but got %d %s", code, closeCode, reason)
+			}
+			if a.debug {
+				fmt.Printf("WebSocket closed with code %d\n", closeCode)
+			}
+			return nil
+		}
+
+		select {
+		case <-arrived:
+		case <-deadline:
+			return fmt.Errorf("the WebSocket did not close within %s", wait)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newEchoServer greets each connection with the tenant header it was opened
+// with, echoes messages back and closes with code 4001 on "bye".
+func newEchoServer() *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "welcome", "tenant": "`+r.Header.Get("X-Tenant")+`"}`))
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(data) == "bye" {
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(4001, "bye"), time.Now().Add(time.Second))
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, data)
+		}
+	}))
+}
+
+func TestWebSocket(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	apiTest := NewAPITest(server.URL)
+	apiTest.headers = map[string]string{"Content-Type": "application/json", "X-Tenant": "acme"}
+	apiTest.store["order_id"] = 7
+	defer apiTest.resetWebSocket()
+
+	if err := apiTest.iSendTheWebSocketMessage("ping"); err == nil {
+		t.Errorf("Expected an error without a connection")
+	}
+
+	if err := apiTest.iConnectToTheWebSocket("/ws"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iSendTheWebSocketMessage(`{"type": "order.created", "data": {"id": ${order_id}}}`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The welcome message arrives first but stays available.
+	if err := apiTest.iShouldReceiveAWebSocketMessageWhereWithin(`data.id == ${order_id}`, "2s"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iStoreTheWebSocketMessagePropertyAs("type", "event"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if apiTest.store["event"] != "order.created" {
+		t.Errorf("Expected event to be order.created, got %v", apiTest.store["event"])
+	}
+	if err := apiTest.iShouldReceiveAWebSocketMessageWhereWithin(`tenant == "acme"`, "2s"); err != nil {
+		t.Errorf("Expected headers to be sent when connecting, got %v", err)
+	}
+
+	err := apiTest.iShouldReceiveAWebSocketMessageWithin("50ms")
+	if err == nil || !strings.Contains(err.Error(), "within 50ms") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+
+	if err := apiTest.iSendTheWebSocketMessage("bye"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theWebSocketShouldCloseWithCodeWithin(1000, "2s"); err == nil {
+		t.Errorf("Expected an error for a different close code")
+	}
+	if err := apiTest.theWebSocketShouldCloseWithCodeWithin(4001, "2s"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestWebSocketURL(t *testing.T) {
+	apiTest := NewAPITest("https://api.example.com")
+
+	tests := map[string]string{
+		"/ws":                    "wss://api.example.com/ws",
+		"ws://localhost:8080/ws": "ws://localhost:8080/ws",
+		"http://localhost/ws":    "ws://localhost/ws",
+	}
+	for endpoint, expected := range tests {
+		actual, err := apiTest.webSocketURL(endpoint)
+		if err != nil || actual != expected {
+			t.Errorf("Expected %s to resolve to %s, got %s (%v)", endpoint, expected, actual, err)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package app
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// xmlPlaceholderPattern matches ${...} placeholders in XML templates.
+var xmlPlaceholderPattern = regexp.MustCompile(`\$\{[^}]+\}`)
+
+func isXMLPayload(payload string) bool {
+	return strings.HasPrefix(strings.TrimSpace(payload), "<")
+}
+
+// renderXML substitutes placeholders in an XML template, escaping their
+// values so the result stays well-formed.
+func (a *APITest) renderXML(template string) (string, error) {
+	var errs []string
+	result := xmlPlaceholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		val, err := a.evalExpr(match[2 : len(match)-1])
+		if err != nil {
+			errs = append(errs, a.describeExprError(err))
+			return match
+		}
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(formatValue(val)))
+		return buf.String()
+	})
+
+	if a.strict && len(errs) > 0 {
+		return "", fmt.Errorf("unresolved placeholders in payload: %s", strings.Join(errs, "; "))
+	}
+
+	return result, nil
+}
+
+func (a *APITest) iUseTheXMLNamespaceFor(prefix, uri string) error {
+	uri, err := a.replaceVars(uri)
+	if err != nil {
+		return err
+	}
+	if a.scenarioXMLNamespaces == nil {
+		a.scenarioXMLNamespaces = map[string]string{}
+	}
+	a.scenarioXMLNamespaces[prefix] = uri
+
+	if a.debug {
+		fmt.Printf("Using XML namespace %s for %s\n", prefix, uri)
+	}
+
+	return nil
+}
+
+// evalXPath evaluates an XPath expression against the response. Prefixes
+// declared in the response can be used directly, and namespaces registered
+// with "I use the XML namespace" take precedence. It returns the string value
+// of the first selected node, or of a number, string or boolean result.
+func (a *APITest) evalXPath(expr string) (string, bool, error) {
+	doc, err := xmlquery.Parse(strings.NewReader(a.responseBody))
+	if err != nil {
+		return "", false, fmt.Errorf("invalid response XML: %w", err)
+	}
+
+	namespaces, err := xmlNamespaces(a.responseBody)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid response XML: %w", err)
+	}
+	maps.Copy(namespaces, a.xmlNamespaces)
+	maps.Copy(namespaces, a.scenarioXMLNamespaces)
+
+	compiled, err := xpath.CompileWithNS(expr, namespaces)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid XPath %q: %w", expr, err)
+	}
+
+	switch result := compiled.Evaluate(xmlquery.CreateXPathNavigator(doc)).(type) {
+	case *xpath.NodeIterator:
+		if !result.MoveNext() {
+			return "", false, nil
+		}
+		return result.Current().Value(), true, nil
+	case float64:
+		return strconv.FormatFloat(result, 'f', -1, 64), true, nil
+	case bool:
+		return strconv.FormatBool(result), true, nil
+	default:
+		return fmt.Sprint(result), true, nil
+	}
+}
+
+// xmlNamespaces collects the prefixed namespace declarations of a document.
+func xmlNamespaces(document string) (map[string]string, error) {
+	namespaces := map[string]string{}
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			return namespaces, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			for _, attr := range start.Attr {
+				if attr.Name.Space == "xmlns" {
+					if _, exists := namespaces[attr.Name.Local]; !exists {
+						namespaces[attr.Name.Local] = attr.Value
+					}
+				}
+			}
+		}
+	}
+}
+
+func (a *APITest) theResponseXPathShouldBe(expr, expectedValue string) error {
+	expected, err := a.replaceVars(expectedValue)
+	if err != nil {
+		return err
+	}
+
+	value, found, err := a.evalXPath(expr)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("XPath %s not found in response %s", expr, a.responseBody)
+	}
+
+	if a.debug {
+		fmt.Printf("XPath %s: expected %s, got %s\n", expr, expected, value)
+	}
+
+	if strings.HasPrefix(expected, "\"") && strings.HasSuffix(expected, "\"") && len(expected) >= 2 {
+		expected = expected[1 : len(expected)-1]
+	} else if expNum, err := strconv.ParseFloat(expected, 64); err == nil {
+		if actual, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && actual == expNum {
+			return nil
+		}
+	}
+
+	if value != expected {
+		return fmt.Errorf("expected XPath %s to be %s but got %s", expr, expected, value)
+	}
+
+	return nil
+}
+
+func (a *APITest) theResponseXPathShouldNotBeEmpty(expr string) error {
+	value, found, err := a.evalXPath(expr)
+	if err != nil {
+		return err
+	}
+	if !found || strings.TrimSpace(value) == "" {
+		return fmt.Errorf("XPath %s is empty or not found", expr)
+	}
+
+	if a.debug {
+		fmt.Printf("XPath %s is not empty: %s\n", expr, value)
+	}
+
+	return nil
+}
+
+func (a *APITest) iStoreTheResponseXPathAs(expr, variable string) error {
+	value, found, err := a.evalXPath(expr)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("XPath %s not found in response %s", expr, a.responseBody)
+	}
+	a.store[variable] = value
+
+	if a.debug {
+		fmt.Printf("Stored XPath %s as %s: %s\n", expr, variable, value)
+	}
+
+	return nil
+}
+
+func (a *APITest) theResponseShouldMatchXML(expected string) error {
+	templated, err := a.renderXML(expected)
+	if err != nil {
+		return err
+	}
+
+	expectedDoc, err := parseXMLTree(templated)
+	if err != nil {
+		return fmt.Errorf("invalid expected XML: %w", err)
+	}
+	actualDoc, err := parseXMLTree(a.responseBody)
+	if err != nil {
+		return fmt.Errorf("invalid response XML: %w", err)
+	}
+
+	if diff := compareXML(expectedDoc, actualDoc, "/"+expectedDoc.name.Local); diff != "" {
+		return fmt.Errorf("XML mismatch: %s", diff)
+	}
+
+	if a.debug {
+		fmt.Printf("XML match successful\n")
+	}
+
+	return nil
+}
+
+// xmlElement is a parsed XML element with namespaces resolved, ignoring
+// comments, processing instructions and whitespace between elements.
+type xmlElement struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     string
+}
+
+func parseXMLTree(document string) (*xmlElement, error) {
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	var root *xmlElement
+	var stack []*xmlElement
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &xmlElement{name: t.Name}
+			for _, attr := range t.Attr {
+				if attr.Name.Space != "xmlns" && !(attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					el.attrs = append(el.attrs, attr)
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			} else if root == nil {
+				root = el
+			}
+			stack = append(stack, el)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// compareXML describes the first difference between two elements, comparing
+// names, attributes regardless of order, trimmed text and children in order.
+func compareXML(expected, actual *xmlElement, path string) string {
+	if expected.name != actual.name {
+		return fmt.Sprintf("at %s expected element %s but got %s", path, xmlNameString(expected.name), xmlNameString(actual.name))
+	}
+
+	expectedAttrs := xmlAttrMap(expected.attrs)
+	actualAttrs := xmlAttrMap(actual.attrs)
+	for _, name := range slices.Sorted(maps.Keys(expectedAttrs)) {
+		value, ok := actualAttrs[name]
+		if !ok {
+			return fmt.Sprintf("at %s missing attribute %s", path, name)
+		}
+		if value != expectedAttrs[name] {
+			return fmt.Sprintf("at %s/@%s expected %q but got %q", path, name, expectedAttrs[name], value)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(actualAttrs)) {
+		if _, ok := expectedAttrs[name]; !ok {
+			return fmt.Sprintf("at %s unexpected attribute %s", path, name)
+		}
+	}
+
+	if len(expected.children) == 0 && len(actual.children) == 0 {
+		if e, a := strings.TrimSpace(expected.text), strings.TrimSpace(actual.text); e != a {
+			return fmt.Sprintf("at %s expected text %q but got %q", path, e, a)
+		}
+		return ""
+	}
+
+	for i, child := range expected.children {
+		childPath := fmt.Sprintf("%s/%s[%d]", path, child.name.Local, i+1)
+		if i >= len(actual.children) {
+			return fmt.Sprintf("at %s missing element %s", childPath, xmlNameString(child.name))
+		}
+		if diff := compareXML(child, actual.children[i], childPath); diff != "" {
+			return diff
+		}
+	}
+	if len(actual.children) > len(expected.children) {
+		extra := actual.children[len(expected.children)]
+		return fmt.Sprintf("at %s unexpected element %s", path, xmlNameString(extra.name))
+	}
+
+	return ""
+}
+
+func xmlAttrMap(attrs []xml.Attr) map[string]string {
+	result := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		result[xmlNameString(attr.Name)] = attr.Value
+	}
+	return result
+}
+
+func xmlNameString(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
@@ -0,0 +1,288 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const soapResponse = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="http://example.com/orders">
+  <soap:Body>
+    <m:OrderResponse>
+      <m:Order id="42">
+        <m:Status>paid</m:Status>
+        <m:Total>19.50</m:Total>
+      </m:Order>
+    </m:OrderResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestXPath(t *testing.T) {
+	configured := map[string]string{"s": "http://schemas.xmlsoap.org/soap/envelope/"}
+	apiTest := NewAPITest("")
+	apiTest.xmlNamespaces = configured
+	apiTest.responseBody = soapResponse
+
+	if err := apiTest.theResponseXPathShouldBe("//m:Order/@id", `"42"`); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := apiTest.theResponseXPathShouldBe("//m:Order/@id", "42"); err != nil {
+		t.Errorf("Expected a numeric match, got %v", err)
+	}
+	if err := apiTest.theResponseXPathShouldBe("//m:Total", "19.5"); err != nil {
+		t.Errorf("Expected a numeric match, got %v", err)
+	}
+	if err := apiTest.theResponseXPathShouldBe("count(//m:Order)", "1"); err != nil {
+		t.Errorf("Expected a count match, got %v", err)
+	}
+	if err := apiTest.theResponseXPathShouldBe("//m:Status", `"pending"`); err == nil {
+		t.Errorf("Expected an error for a different value")
+	}
+	if err := apiTest.theResponseXPathShouldNotBeEmpty("//m:Missing"); err == nil {
+		t.Errorf("Expected an error for a missing node")
+	}
+
+	if err := apiTest.iUseTheXMLNamespaceFor("o", "http://example.com/orders"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := apiTest.iStoreTheResponseXPathAs("//o:Order/o:Status", "status"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if apiTest.store["status"] != "paid" {
+		t.Errorf("Expected status to be paid, got %v", apiTest.store["status"])
+	}
+	if err := apiTest.theResponseXPathShouldNotBeEmpty("//s:Body"); err != nil {
+		t.Errorf("Expected configured namespaces to apply, got %v", err)
+	}
+	if _, ok := configured["o"]; ok {
+		t.Errorf("Expected the config namespaces not to change, got %v", configured)
+	}
+}
+
+func TestRenderXML(t *testing.T) {
+	apiTest := NewAPITest("")
+	apiTest.store["name"] = "Tom & Jerry <3"
+
+	result, err := apiTest.renderXML(`<customer><name>${name}</name></customer>`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result != `<customer><name>Tom &amp; Jerry &lt;3</name></customer>` {
+		t.Errorf("Expected placeholders to be escaped, got %s", result)
+	}
+}
+
+func TestResponseShouldMatchXML(t *testing.T) {
+	apiTest := NewAPITest("")
+	apiTest.responseBody = soapResponse
+	apiTest.store["order_id"] = 42
+
+	expected := `<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/">
+  <Body>
+    <OrderResponse xmlns="http://example.com/orders">
+      <Order id="${order_id}"><Status>paid</Status><Total>19.50</Total></Order>
+    </OrderResponse>
+  </Body>
+</Envelope>`
+	if err := apiTest.theResponseShouldMatchXML(expected); err != nil {
+		t.Errorf("Expected documents differing only in prefixes to match, got %v", err)
+	}
+
+	mismatch := strings.Replace(expected, "paid", "refunded", 1)
+	err := apiTest.theResponseShouldMatchXML(mismatch)
+	if err == nil || !strings.Contains(err.Error(), "Status[1]") {
+		t.Errorf("Expected the mismatch path in the error, got %v", err)
+	}
+}
+
+func TestResponseShouldMatchXSD(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "order.xsd")
+	os.WriteFile(schema, []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="status">
+          <xs:simpleType>
+            <xs:restriction base="xs:string">
+              <xs:enumeration value="pending"/>
+              <xs:enumeration value="paid"/>
+            </xs:restriction>
+          </xs:simpleType>
+        </xs:element>
+        <xs:element name="item" type="xs:string" maxOccurs="unbounded"/>
+      </xs:sequence>
+      <xs:attribute name="id" type="xs:int" use="required"/>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`), 0o644)
+
+	apiTest := NewAPITest("")
+	apiTest.responseBody = `<order id="42"><status>paid</status><item>A1</item><item>B2</item></order>`
+	if err := apiTest.theResponseShouldMatchXSD(schema); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	invalid := map[string]string{
+		"enumeration":       `<order id="42"><status>lost</status><item>A1</item></order>`,
+		"missing element":   `<order id="42"><status>paid</status></order>`,
+		"attribute type":    `<order id="abc"><status>paid</status><item>A1</item></order>`,
+		"missing attribute": `<order><status>paid</status><item>A1</item></order>`,
+	}
+	for name, body := range invalid {
+		apiTest.responseBody = body
+		if err := apiTest.theResponseShouldMatchXSD(schema); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
+
+func TestResponseShouldMatchXSDNamespaces(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "order.xsd")
+	os.WriteFile(schema, []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="http://example.com/orders"
+    targetNamespace="http://example.com/orders" elementFormDefault="qualified">
+  <xs:include schemaLocation="types.xsd"/>
+  <xs:element name="order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="status" type="o:status"/>
+        <xs:element name="note" type="xs:string" form="unqualified" minOccurs="0"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`), 0o644)
+	os.WriteFile(filepath.Join(dir, "types.xsd"), []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:simpleType name="status">
+    <xs:restriction base="xs:string"/>
+  </xs:simpleType>
+</xs:schema>`), 0o644)
+
+	apiTest := NewAPITest("")
+	apiTest.responseBody = `<o:order xmlns:o="http://example.com/orders"><o:status>paid</o:status><note>gift</note></o:order>`
+	if err := apiTest.theResponseShouldMatchXSD(schema); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	invalid := map[string]string{
+		"root without namespace":  `<order><status>paid</status></order>`,
+		"root in other namespace": `<order xmlns="http://example.com/other"><status>paid</status></order>`,
+		"unqualified child":       `<o:order xmlns:o="http://example.com/orders"><status>paid</status></o:order>`,
+		"qualified local element": `<order xmlns="http://example.com/orders"><status>paid</status><note>gift</note></order>`,
+	}
+	for name, body := range invalid {
+		apiTest.responseBody = body
+		err := apiTest.theResponseShouldMatchXSD(schema)
+		if err == nil || !strings.Contains(err.Error(), "namespace") {
+			t.Errorf("Expected a namespace error for %s, got %v", name, err)
+		}
+	}
+
+	// Names shared across namespaces cannot be told apart by local name.
+	os.WriteFile(filepath.Join(dir, "other.xsd"), []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="http://example.com/other">
+  <xs:element name="order" type="xs:string"/>
+</xs:schema>`), 0o644)
+	shared := filepath.Join(dir, "shared.xsd")
+	os.WriteFile(shared, []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="http://example.com/orders">
+  <xs:import namespace="http://example.com/other" schemaLocation="other.xsd"/>
+  <xs:element name="order" type="xs:string"/>
+</xs:schema>`), 0o644)
+	err := apiTest.theResponseShouldMatchXSD(shared)
+	if err == nil || !strings.Contains(err.Error(), "defined in both") {
+		t.Errorf("Expected an unsupported schema error, got %v", err)
+	}
+}
+
+func TestResponseShouldMatchXSDContentModels(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "shop.xsd")
+	os.WriteFile(schema, []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="base">
+    <xs:sequence>
+      <xs:element name="code">
+        <xs:simpleType>
+          <xs:restriction base="xs:string">
+            <xs:pattern value="[A-Z]+"/>
+            <xs:pattern value="[0-9]+"/>
+            <xs:maxLength value="4"/>
+          </xs:restriction>
+        </xs:simpleType>
+      </xs:element>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:element name="shop">
+    <xs:complexType>
+      <xs:complexContent>
+        <xs:extension base="base">
+          <xs:sequence>
+            <xs:choice>
+              <xs:element name="card" type="xs:string"/>
+              <xs:element name="cash" type="xs:string"/>
+            </xs:choice>
+            <xs:element name="details">
+              <xs:complexType>
+                <xs:all>
+                  <xs:element name="stock" type="xs:short"/>
+                  <xs:element name="rating" minOccurs="0">
+                    <xs:simpleType>
+                      <xs:restriction base="xs:decimal">
+                        <xs:minInclusive value="1"/>
+                        <xs:maxExclusive value="5"/>
+                      </xs:restriction>
+                    </xs:simpleType>
+                  </xs:element>
+                </xs:all>
+              </xs:complexType>
+            </xs:element>
+            <xs:element name="tag" type="xs:unsignedByte" minOccurs="1" maxOccurs="2"/>
+          </xs:sequence>
+        </xs:extension>
+      </xs:complexContent>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`), 0o644)
+
+	apiTest := NewAPITest("")
+	valid := map[string]string{
+		"letters":  `<shop><code>ABC</code><card>visa</card><details><stock>3</stock></details><tag>1</tag></shop>`,
+		"digits":   `<shop><code>123</code><cash>aud</cash><details><rating>4.5</rating><stock>-3</stock></details><tag>1</tag><tag>255</tag></shop>`,
+		"all bare": `<shop><code>X</code><cash>aud</cash><details><stock>32767</stock></details><tag>0</tag></shop>`,
+	}
+	for name, body := range valid {
+		apiTest.responseBody = body
+		if err := apiTest.theResponseShouldMatchXSD(schema); err != nil {
+			t.Errorf("Expected no error for %s, got %v", name, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"no pattern matches":   `<shop><code>A1</code><card>visa</card><details><stock>3</stock></details><tag>1</tag></shop>`,
+		"max length":           `<shop><code>ABCDE</code><card>visa</card><details><stock>3</stock></details><tag>1</tag></shop>`,
+		"missing base element": `<shop><card>visa</card><details><stock>3</stock></details><tag>1</tag></shop>`,
+		"no choice":            `<shop><code>ABC</code><details><stock>3</stock></details><tag>1</tag></shop>`,
+		"both choices":         `<shop><code>ABC</code><card>visa</card><cash>aud</cash><details><stock>3</stock></details><tag>1</tag></shop>`,
+		"all missing element":  `<shop><code>ABC</code><card>visa</card><details><rating>2</rating></details><tag>1</tag></shop>`,
+		"all repeated element": `<shop><code>ABC</code><card>visa</card><details><stock>3</stock><stock>4</stock></details><tag>1</tag></shop>`,
+		"short overflow":       `<shop><code>ABC</code><card>visa</card><details><stock>32768</stock></details><tag>1</tag></shop>`,
+		"min inclusive":        `<shop><code>ABC</code><card>visa</card><details><stock>3</stock><rating>0.5</rating></details><tag>1</tag></shop>`,
+		"max exclusive":        `<shop><code>ABC</code><card>visa</card><details><stock>3</stock><rating>5</rating></details><tag>1</tag></shop>`,
+		"unsigned byte":        `<shop><code>ABC</code><card>visa</card><details><stock>3</stock></details><tag>256</tag></shop>`,
+		"below minOccurs":      `<shop><code>ABC</code><card>visa</card><details><stock>3</stock></details></shop>`,
+		"above maxOccurs":      `<shop><code>ABC</code><card>visa</card><details><stock>3</stock></details><tag>1</tag><tag>2</tag><tag>3</tag></shop>`,
+	}
+	for name, body := range invalid {
+		apiTest.responseBody = body
+		if err := apiTest.theResponseShouldMatchXSD(schema); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+
+	// Alternative patterns are reported together.
+	apiTest.responseBody = invalid["no pattern matches"]
+	err := apiTest.theResponseShouldMatchXSD(schema)
+	if err == nil || !strings.Contains(err.Error(), `does not match pattern "[A-Z]+" or "[0-9]+"`) || strings.Count(err.Error(), "does not match pattern") != 1 {
+		t.Errorf("Expected one pattern error, got %v", err)
+	}
+}
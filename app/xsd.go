@@ -0,0 +1,701 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (a *APITest) theResponseShouldMatchXSD(path string) error {
+	path, err := a.replaceVars(path)
+	if err != nil {
+		return err
+	}
+
+	schema, err := loadXSD(path)
+	if err != nil {
+		return err
+	}
+
+	doc, err := parseXMLTree(a.responseBody)
+	if err != nil {
+		return fmt.Errorf("invalid response XML: %w", err)
+	}
+
+	errs := schema.validate(doc)
+	if len(errs) > maxSchemaErrors {
+		errs = append(errs[:maxSchemaErrors], fmt.Sprintf("and %d more", len(errs)-maxSchemaErrors))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("response does not match XSD %s:\n  %s", path, strings.Join(errs, "\n  "))
+	}
+
+	if a.debug {
+		fmt.Printf("Response matches XSD %s\n", path)
+	}
+
+	return nil
+}
+
+// xsdSchema validates documents against the common subset of XML Schema:
+// global and local element declarations and references, named and inline
+// complex and simple types, sequences, choices and all groups with
+// occurrence bounds, attributes, simple and complex content extensions,
+// built-in types and restriction facets. Definitions are looked up by local
+// name, and the namespace of each element is checked against its schema's
+// targetNamespace and elementFormDefault. Attributes are matched by local
+// name.
+type xsdSchema struct {
+	elements     map[string]*xmlElement
+	complexTypes map[string]*xmlElement
+	simpleTypes  map[string]*xmlElement
+	groups       map[string]*xmlElement
+	attrGroups   map[string]*xmlElement
+	// namespaces holds the namespace expected for each element declaration.
+	namespaces map[*xmlElement]string
+	// defined holds the namespace each definition was declared in, keyed by
+	// kind and name, to detect names shared across namespaces.
+	defined map[string]string
+}
+
+func loadXSD(path string) (*xsdSchema, error) {
+	s := &xsdSchema{
+		elements:     map[string]*xmlElement{},
+		complexTypes: map[string]*xmlElement{},
+		simpleTypes:  map[string]*xmlElement{},
+		groups:       map[string]*xmlElement{},
+		attrGroups:   map[string]*xmlElement{},
+		namespaces:   map[*xmlElement]string{},
+		defined:      map[string]string{},
+	}
+	if err := s.load(path, "", map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the definitions of an XSD file and the files it includes or
+// imports. An included schema without a targetNamespace takes the one of the
+// schema including it.
+func (s *xsdSchema) load(path, includingNamespace string, loaded map[string]bool) error {
+	if loaded[path] {
+		return nil
+	}
+	loaded[path] = true
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read XSD: %w", err)
+	}
+	root, err := parseXMLTree(string(data))
+	if err != nil {
+		return fmt.Errorf("invalid XSD %s: %w", path, err)
+	}
+	if root.name.Local != "schema" {
+		return fmt.Errorf("invalid XSD %s: root element is %s, expected schema", path, root.name.Local)
+	}
+
+	target := xmlAttr(root, "targetNamespace")
+	if target == "" {
+		target = includingNamespace
+	}
+	qualified := xmlAttr(root, "elementFormDefault") == "qualified"
+
+	for _, def := range root.children {
+		name := xmlAttr(def, "name")
+		switch def.name.Local {
+		case "element", "complexType", "simpleType", "group", "attributeGroup":
+			// Lookups are by local name, so the same name in two
+			// namespaces cannot be told apart.
+			key := def.name.Local + " " + name
+			if ns, ok := s.defined[key]; ok && ns != target {
+				return fmt.Errorf("unsupported XSD %s: %s %s is defined in both %s and %s", path, def.name.Local, name, describeNamespace(ns), describeNamespace(target))
+			}
+			s.defined[key] = target
+		}
+
+		switch def.name.Local {
+		case "element":
+			s.elements[name] = def
+			s.namespaces[def] = target
+		case "complexType":
+			s.complexTypes[name] = def
+		case "simpleType":
+			s.simpleTypes[name] = def
+		case "group":
+			s.groups[name] = def
+		case "attributeGroup":
+			s.attrGroups[name] = def
+		case "include", "import":
+			if location := xmlAttr(def, "schemaLocation"); location != "" {
+				including := ""
+				if def.name.Local == "include" {
+					including = target
+				}
+				if err := s.load(filepath.Join(filepath.Dir(path), location), including, loaded); err != nil {
+					return err
+				}
+			}
+		}
+		s.localNamespaces(def, target, qualified)
+	}
+
+	return nil
+}
+
+// localNamespaces records the namespace of the local element declarations
+// under def: the target namespace when they are qualified, none otherwise.
+func (s *xsdSchema) localNamespaces(def *xmlElement, target string, qualified bool) {
+	for _, child := range def.children {
+		if child.name.Local == "annotation" {
+			continue
+		}
+		if child.name.Local == "element" && xmlAttr(child, "name") != "" {
+			form := xmlAttr(child, "form")
+			if form == "qualified" || (form == "" && qualified) {
+				s.namespaces[child] = target
+			} else {
+				s.namespaces[child] = ""
+			}
+		}
+		s.localNamespaces(child, target, qualified)
+	}
+}
+
+func describeNamespace(ns string) string {
+	if ns == "" {
+		return "no namespace"
+	}
+	return fmt.Sprintf("namespace %q", ns)
+}
+
+func (s *xsdSchema) validate(doc *xmlElement) []string {
+	decl, ok := s.elements[doc.name.Local]
+	if !ok {
+		return []string{fmt.Sprintf("/%s is not declared in the schema", doc.name.Local)}
+	}
+	return s.validateElement(doc, decl, "/"+doc.name.Local)
+}
+
+// validateElement checks an element against its declaration.
+func (s *xsdSchema) validateElement(el, decl *xmlElement, path string) []string {
+	if ref := xmlAttr(decl, "ref"); ref != "" {
+		global, ok := s.elements[localName(ref)]
+		if !ok {
+			return []string{fmt.Sprintf("%s: element %s is not declared in the schema", path, ref)}
+		}
+		decl = global
+	}
+
+	if ns, ok := s.namespaces[decl]; ok && el.name.Space != ns {
+		return []string{fmt.Sprintf("%s: element is in %s, expected %s", path, describeNamespace(el.name.Space), describeNamespace(ns))}
+	}
+
+	if typeName := xmlAttr(decl, "type"); typeName != "" {
+		return s.validateType(el, localName(typeName), path)
+	}
+	for _, child := range decl.children {
+		switch child.name.Local {
+		case "complexType":
+			return s.validateComplex(el, child, path)
+		case "simpleType":
+			return s.validateElementText(el, path, func(value string) []string {
+				return s.validateSimple(value, child, path)
+			})
+		}
+	}
+
+	// Elements without a type accept any content.
+	return nil
+}
+
+func (s *xsdSchema) validateType(el *xmlElement, typeName, path string) []string {
+	if complexType, ok := s.complexTypes[typeName]; ok {
+		return s.validateComplex(el, complexType, path)
+	}
+	return s.validateElementText(el, path, func(value string) []string {
+		return s.validateSimpleType(value, typeName, path)
+	})
+}
+
+// validateElementText checks an element with simple content: no child
+// elements and no attributes besides schema instance ones.
+func (s *xsdSchema) validateElementText(el *xmlElement, path string, validate func(value string) []string) []string {
+	var errs []string
+	for _, child := range el.children {
+		errs = append(errs, fmt.Sprintf("%s/%s is not allowed in a simple value", path, child.name.Local))
+	}
+	for _, attr := range el.attrs {
+		if !isSchemaInstanceAttr(attr.Name.Space) {
+			errs = append(errs, fmt.Sprintf("%s/@%s is not allowed", path, attr.Name.Local))
+		}
+	}
+	return append(errs, validate(el.text)...)
+}
+
+func (s *xsdSchema) validateComplex(el, complexType *xmlElement, path string) []string {
+	var particle *xmlElement
+	var attributes []*xmlElement
+	anyAttribute := false
+	var errs []string
+
+	s.collectComplexType(complexType, &particle, &attributes, &anyAttribute)
+
+	for _, child := range complexType.children {
+		if child.name.Local != "simpleContent" {
+			continue
+		}
+		for _, ext := range child.children {
+			if ext.name.Local == "extension" || ext.name.Local == "restriction" {
+				base := localName(xmlAttr(ext, "base"))
+				errs = append(errs, s.validateSimpleType(el.text, base, path)...)
+			}
+		}
+	}
+
+	errs = append(errs, s.validateAttributes(el, attributes, anyAttribute, path)...)
+
+	if particle == nil {
+		for _, child := range el.children {
+			errs = append(errs, fmt.Sprintf("%s/%s is not allowed", path, child.name.Local))
+		}
+		return errs
+	}
+
+	pos, particleErrs := s.matchParticle(particle, el.children, 0, path)
+	errs = append(errs, particleErrs...)
+	for _, child := range el.children[pos:] {
+		errs = append(errs, fmt.Sprintf("%s/%s is not allowed here", path, child.name.Local))
+	}
+
+	return errs
+}
+
+// collectComplexType gathers the content model and attributes of a complex
+// type, following complex content extensions to their base types.
+func (s *xsdSchema) collectComplexType(complexType *xmlElement, particle **xmlElement, attributes *[]*xmlElement, anyAttribute *bool) {
+	for _, child := range complexType.children {
+		switch child.name.Local {
+		case "sequence", "choice", "all", "group":
+			*particle = combineParticles(*particle, child)
+		case "attribute", "attributeGroup":
+			*attributes = append(*attributes, child)
+		case "anyAttribute":
+			*anyAttribute = true
+		case "simpleContent", "complexContent":
+			for _, derivation := range child.children {
+				if derivation.name.Local != "extension" && derivation.name.Local != "restriction" {
+					continue
+				}
+				if base, ok := s.complexTypes[localName(xmlAttr(derivation, "base"))]; ok && derivation.name.Local == "extension" {
+					s.collectComplexType(base, particle, attributes, anyAttribute)
+				}
+				s.collectComplexType(derivation, particle, attributes, anyAttribute)
+			}
+		}
+	}
+}
+
+// combineParticles appends a particle to the content model, as extending a
+// base type does.
+func combineParticles(first, second *xmlElement) *xmlElement {
+	if first == nil {
+		return second
+	}
+	return &xmlElement{name: xml.Name{Local: "sequence"}, children: []*xmlElement{first, second}}
+}
+
+func (s *xsdSchema) validateAttributes(el *xmlElement, declared []*xmlElement, anyAttribute bool, path string) []string {
+	var errs []string
+	known := map[string]bool{}
+
+	var declare func(attrs []*xmlElement)
+	declare = func(attrs []*xmlElement) {
+		for _, decl := range attrs {
+			if decl.name.Local == "attributeGroup" {
+				if group, ok := s.attrGroups[localName(xmlAttr(decl, "ref"))]; ok {
+					declare(group.children)
+				}
+				continue
+			}
+			if decl.name.Local != "attribute" {
+				continue
+			}
+
+			name := xmlAttr(decl, "name")
+			if ref := xmlAttr(decl, "ref"); ref != "" {
+				name = localName(ref)
+			}
+			known[name] = true
+
+			value, present := "", false
+			for _, attr := range el.attrs {
+				if attr.Name.Local == name {
+					value, present = attr.Value, true
+				}
+			}
+			if !present {
+				if xmlAttr(decl, "use") == "required" {
+					errs = append(errs, fmt.Sprintf("%s/@%s is required", path, name))
+				}
+				continue
+			}
+
+			attrPath := path + "/@" + name
+			if typeName := xmlAttr(decl, "type"); typeName != "" {
+				errs = append(errs, s.validateSimpleType(value, localName(typeName), attrPath)...)
+			}
+			for _, child := range decl.children {
+				if child.name.Local == "simpleType" {
+					errs = append(errs, s.validateSimple(value, child, attrPath)...)
+				}
+			}
+		}
+	}
+	declare(declared)
+
+	if !anyAttribute {
+		for _, attr := range el.attrs {
+			if !known[attr.Name.Local] && !isSchemaInstanceAttr(attr.Name.Space) {
+				errs = append(errs, fmt.Sprintf("%s/@%s is not allowed", path, attr.Name.Local))
+			}
+		}
+	}
+
+	return errs
+}
+
+// matchParticle matches children from pos against a content model
+// particle, returning the position after the children it consumed.
+func (s *xsdSchema) matchParticle(particle *xmlElement, children []*xmlElement, pos int, path string) (int, []string) {
+	minOccurs, maxOccurs := occurrences(particle)
+	var errs []string
+
+	count := 0
+	for count < maxOccurs {
+		next, particleErrs, matched := s.matchOnce(particle, children, pos, path)
+		if !matched {
+			break
+		}
+		errs = append(errs, particleErrs...)
+		count++
+		if next == pos {
+			// An empty match would repeat forever.
+			break
+		}
+		pos = next
+	}
+
+	if count < minOccurs {
+		errs = append(errs, fmt.Sprintf("%s is missing %s", path, describeParticle(particle)))
+	}
+	return pos, errs
+}
+
+// matchOnce matches a single occurrence of a particle, reporting whether it
+// matched at all.
+func (s *xsdSchema) matchOnce(particle *xmlElement, children []*xmlElement, pos int, path string) (int, []string, bool) {
+	switch particle.name.Local {
+	case "element":
+		if pos >= len(children) || children[pos].name.Local != particleElementName(particle) {
+			return pos, nil, false
+		}
+		child := children[pos]
+		return pos + 1, s.validateElement(child, particle, fmt.Sprintf("%s/%s", path, child.name.Local)), true
+
+	case "any":
+		if pos >= len(children) {
+			return pos, nil, false
+		}
+		return pos + 1, nil, true
+
+	case "group":
+		group, ok := s.groups[localName(xmlAttr(particle, "ref"))]
+		if !ok {
+			return pos, []string{fmt.Sprintf("%s: group %s is not declared in the schema", path, xmlAttr(particle, "ref"))}, true
+		}
+		for _, child := range group.children {
+			if isParticle(child) {
+				next, errs := s.matchParticle(child, children, pos, path)
+				return next, errs, next > pos || len(errs) == 0
+			}
+		}
+		return pos, nil, true
+
+	case "sequence":
+		var errs []string
+		start := pos
+		for _, item := range particle.children {
+			if !isParticle(item) {
+				continue
+			}
+			var itemErrs []string
+			pos, itemErrs = s.matchParticle(item, children, pos, path)
+			errs = append(errs, itemErrs...)
+		}
+		// A sequence that consumed nothing and failed did not occur.
+		if pos == start && len(errs) > 0 {
+			return pos, errs, false
+		}
+		return pos, errs, true
+
+	case "choice":
+		for _, option := range particle.children {
+			if !isParticle(option) {
+				continue
+			}
+			next, errs := s.matchParticle(option, children, pos, path)
+			if next > pos {
+				return next, errs, true
+			}
+		}
+		return pos, nil, false
+
+	case "all":
+		var errs []string
+		seen := map[string]bool{}
+		for pos < len(children) {
+			idx := slices.IndexFunc(particle.children, func(item *xmlElement) bool {
+				return item.name.Local == "element" && particleElementName(item) == children[pos].name.Local
+			})
+			if idx < 0 || seen[children[pos].name.Local] {
+				break
+			}
+			child := children[pos]
+			seen[child.name.Local] = true
+			errs = append(errs, s.validateElement(child, particle.children[idx], fmt.Sprintf("%s/%s", path, child.name.Local))...)
+			pos++
+		}
+		for _, item := range particle.children {
+			if item.name.Local == "element" && !seen[particleElementName(item)] {
+				if minOccurs, _ := occurrences(item); minOccurs > 0 {
+					errs = append(errs, fmt.Sprintf("%s is missing element %s", path, particleElementName(item)))
+				}
+			}
+		}
+		return pos, errs, true
+
+	default:
+		return pos, nil, false
+	}
+}
+
+func isParticle(el *xmlElement) bool {
+	switch el.name.Local {
+	case "element", "any", "group", "sequence", "choice", "all":
+		return true
+	}
+	return false
+}
+
+func particleElementName(el *xmlElement) string {
+	if ref := xmlAttr(el, "ref"); ref != "" {
+		return localName(ref)
+	}
+	return xmlAttr(el, "name")
+}
+
+func describeParticle(particle *xmlElement) string {
+	switch particle.name.Local {
+	case "element":
+		return "element " + particleElementName(particle)
+	case "choice":
+		var names []string
+		for _, option := range particle.children {
+			if option.name.Local == "element" {
+				names = append(names, particleElementName(option))
+			}
+		}
+		if len(names) > 0 {
+			return "one of " + strings.Join(names, ", ")
+		}
+	}
+	return "a required " + particle.name.Local
+}
+
+// occurrences returns a particle's minOccurs and maxOccurs, both defaulting
+// to 1.
+func occurrences(particle *xmlElement) (int, int) {
+	minOccurs, maxOccurs := 1, 1
+	if v, err := strconv.Atoi(xmlAttr(particle, "minOccurs")); err == nil {
+		minOccurs = v
+	}
+	switch v := xmlAttr(particle, "maxOccurs"); v {
+	case "unbounded":
+		maxOccurs = math.MaxInt
+	case "":
+	default:
+		if n, err := strconv.Atoi(v); err == nil {
+			maxOccurs = n
+		}
+	}
+	return minOccurs, maxOccurs
+}
+
+func (s *xsdSchema) validateSimpleType(value, typeName, path string) []string {
+	if simpleType, ok := s.simpleTypes[typeName]; ok {
+		return s.validateSimple(value, simpleType, path)
+	}
+	if err := validateBuiltinType(value, typeName); err != nil {
+		return []string{fmt.Sprintf("%s %v", path, err)}
+	}
+	return nil
+}
+
+// validateSimple checks a value against a simpleType definition's
+// restriction. Lists and unions are accepted as is.
+func (s *xsdSchema) validateSimple(value string, simpleType *xmlElement, path string) []string {
+	for _, restriction := range simpleType.children {
+		if restriction.name.Local != "restriction" {
+			continue
+		}
+
+		var errs []string
+		if base := xmlAttr(restriction, "base"); base != "" {
+			errs = s.validateSimpleType(value, localName(base), path)
+		}
+		if !slices.Contains(xsdStringTypes, localName(xmlAttr(restriction, "base"))) {
+			value = strings.TrimSpace(value)
+		}
+
+		// Patterns of the same restriction are alternatives, like
+		// enumerations.
+		var enumeration, patterns []string
+		matched := false
+		for _, facet := range restriction.children {
+			limit := xmlAttr(facet, "value")
+			switch facet.name.Local {
+			case "enumeration":
+				enumeration = append(enumeration, limit)
+			case "pattern":
+				re, err := regexp.Compile("^(?:" + limit + ")$")
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("%s: invalid pattern %q in XSD: %v", path, limit, err))
+					continue
+				}
+				patterns = append(patterns, strconv.Quote(limit))
+				matched = matched || re.MatchString(value)
+			case "length", "minLength", "maxLength":
+				n, _ := strconv.Atoi(limit)
+				length := len([]rune(value))
+				if (facet.name.Local == "length" && length != n) || (facet.name.Local == "minLength" && length < n) ||
+					(facet.name.Local == "maxLength" && length > n) {
+					errs = append(errs, fmt.Sprintf("%s is %d characters long, expected %s %d", path, length, facet.name.Local, n))
+				}
+			case "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+				actual, err1 := strconv.ParseFloat(value, 64)
+				bound, err2 := strconv.ParseFloat(limit, 64)
+				if err1 != nil || err2 != nil {
+					continue
+				}
+				if (facet.name.Local == "minInclusive" && actual < bound) || (facet.name.Local == "maxInclusive" && actual > bound) ||
+					(facet.name.Local == "minExclusive" && actual <= bound) || (facet.name.Local == "maxExclusive" && actual >= bound) {
+					errs = append(errs, fmt.Sprintf("%s %s violates %s %s", path, value, facet.name.Local, limit))
+				}
+			}
+		}
+		if len(enumeration) > 0 && !slices.Contains(enumeration, value) {
+			errs = append(errs, fmt.Sprintf("%s %q is not one of %s", path, value, strings.Join(enumeration, ", ")))
+		}
+		if len(patterns) > 0 && !matched {
+			errs = append(errs, fmt.Sprintf("%s %q does not match pattern %s", path, value, strings.Join(patterns, " or ")))
+		}
+		return errs
+	}
+	return nil
+}
+
+// xsdStringTypes are the built-in types whose whitespace is significant.
+var xsdStringTypes = []string{"string", "normalizedString"}
+
+// xsdIntegerBits returns the size of a bounded integer type, signed or
+// unsigned. integer and nonNegativeInteger are checked as 64-bit values.
+func xsdIntegerBits(typeName string) int {
+	switch strings.ToLower(strings.TrimPrefix(typeName, "unsigned")) {
+	case "int":
+		return 32
+	case "short":
+		return 16
+	case "byte":
+		return 8
+	}
+	return 64
+}
+
+// validateBuiltinType checks a value against an XML Schema built-in type.
+// Unknown types accept any value.
+func validateBuiltinType(value, typeName string) error {
+	if !slices.Contains(xsdStringTypes, typeName) {
+		value = strings.TrimSpace(value)
+	}
+
+	valid := true
+	switch typeName {
+	case "boolean":
+		valid = slices.Contains([]string{"true", "false", "1", "0"}, value)
+	case "decimal", "float", "double":
+		_, err := strconv.ParseFloat(value, 64)
+		valid = err == nil || (typeName != "decimal" && slices.Contains([]string{"INF", "-INF", "NaN"}, value))
+	case "integer", "long", "int", "short", "byte":
+		_, err := strconv.ParseInt(value, 10, xsdIntegerBits(typeName))
+		valid = err == nil
+	case "nonNegativeInteger", "unsignedLong", "unsignedInt", "unsignedShort", "unsignedByte":
+		_, err := strconv.ParseUint(value, 10, xsdIntegerBits(typeName))
+		valid = err == nil
+	case "positiveInteger":
+		n, err := strconv.ParseUint(value, 10, 64)
+		valid = err == nil && n > 0
+	case "negativeInteger", "nonPositiveInteger":
+		n, err := strconv.ParseInt(value, 10, 64)
+		valid = err == nil && (n < 0 || (n == 0 && typeName == "nonPositiveInteger"))
+	case "date":
+		_, err := time.Parse(time.DateOnly, strings.TrimSuffix(value, "Z"))
+		valid = err == nil
+	case "dateTime":
+		_, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			_, err = time.Parse("2006-01-02T15:04:05", value)
+		}
+		valid = err == nil
+	case "time":
+		_, err := time.Parse(time.TimeOnly, strings.TrimSuffix(value, "Z"))
+		valid = err == nil
+	case "base64Binary":
+		_, err := base64.StdEncoding.DecodeString(value)
+		valid = err == nil
+	}
+
+	if !valid {
+		return fmt.Errorf("%q is not a valid %s", value, typeName)
+	}
+	return nil
+}
+
+func xmlAttr(el *xmlElement, name string) string {
+	for _, attr := range el.attrs {
+		if attr.Name.Local == name && attr.Name.Space == "" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// localName strips the namespace prefix from a QName such as "xs:string".
+func localName(qname string) string {
+	if i := strings.LastIndex(qname, ":"); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
+
+func isSchemaInstanceAttr(space string) bool {
+	return space == "http://www.w3.org/2001/XMLSchema-instance"
+}
@@ -0,0 +1,43 @@
+/*
+Copyright © 2025 Dave Savic
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/davesavic/rbdd/app"
+	"github.com/spf13/cobra"
+)
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate starter feature files from an OpenAPI specification",
+	Long: `Generate one feature file per tag (or resource) of an OpenAPI 3 specification,
+with a scenario per operation that sends a request with a fake payload built
+from the request schema, and checks the status and response schema.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		spec, _ := cmd.Flags().GetString("from-openapi")
+		if spec == "" {
+			return fmt.Errorf("--from-openapi is required")
+		}
+
+		features, err := app.GenerateFeatures(spec)
+		if err != nil {
+			return err
+		}
+
+		return writeFeatures(cmd, features)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().String("from-openapi", "", "OpenAPI 3 specification (JSON or YAML) to generate features from")
+	generateCmd.Flags().StringP("out", "o", "features", "Directory to write the feature files to")
+	generateCmd.Flags().Bool("force", false, "Overwrite existing feature files")
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 Dave Savic
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/davesavic/rbdd/app"
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Convert Postman collections and HAR files into feature files",
+	Long: `Convert requests from other tools into feature files using rbdd's steps,
+reporting anything that could not be translated.`,
+}
+
+var importPostmanCmd = &cobra.Command{
+	Use:   "postman COLLECTION",
+	Short: "Convert a Postman collection into feature files",
+	Long: `Convert a Postman v2 collection into one feature per top-level folder.
+Requests become scenarios, collection and environment variables are stored in
+each feature's background, and simple pm.test status and JSON assertions
+become steps.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		environment, _ := cmd.Flags().GetString("environment")
+
+		result, err := app.ImportPostman(args[0], environment)
+		if err != nil {
+			return err
+		}
+		return writeImport(cmd, result)
+	},
+}
+
+var importHARCmd = &cobra.Command{
+	Use:   "har FILE",
+	Short: "Convert the API calls in a HAR file into a feature file",
+	Long: `Convert the API calls recorded in a HAR file, as exported from a browser's
+developer tools, into a feature with a scenario per request.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		result, err := app.ImportHAR(args[0])
+		if err != nil {
+			return err
+		}
+		return writeImport(cmd, result)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importPostmanCmd)
+	importCmd.AddCommand(importHARCmd)
+
+	importCmd.PersistentFlags().StringP("out", "o", "features", "Directory to write the feature files to")
+	importCmd.PersistentFlags().Bool("force", false, "Overwrite existing feature files")
+	importPostmanCmd.Flags().StringP("environment", "e", "", "Postman environment file whose variables are imported too")
+}
+
+// writeImport writes the imported features and prints what could not be
+// translated.
+func writeImport(cmd *cobra.Command, result *app.ImportResult) error {
+	if len(result.Features) == 0 {
+		return fmt.Errorf("no requests found to import")
+	}
+
+	if err := writeFeatures(cmd, result.Features); err != nil {
+		return err
+	}
+
+	if len(result.Notes) > 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "\nReview the following:")
+		for _, note := range result.Notes {
+			fmt.Fprintf(cmd.OutOrStdout(), "  - %s\n", note)
+		}
+	}
+
+	return nil
+}
+
+// writeFeatures writes features to the --out directory, refusing to
+// overwrite existing files unless --force is given.
+func writeFeatures(cmd *cobra.Command, features map[string]string) error {
+	out, _ := cmd.Flags().GetString("out")
+	force, _ := cmd.Flags().GetBool("force")
+
+	names := slices.Sorted(maps.Keys(features))
+	if !force {
+		var existing []string
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(out, name)); err == nil {
+				existing = append(existing, name)
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("refusing to overwrite existing files in %s: %s (use --force)", out, strings.Join(existing, ", "))
+		}
+	}
+
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+	for _, name := range names {
+		target := filepath.Join(out, name)
+		if err := os.WriteFile(target, []byte(features[name]), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Created %s\n", target)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 Dave Savic
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const sampleFeature = `Feature: Example
+  A starting point for testing the service. Run it with "rbdd run" and list
+  every available step with "rbdd syntax".
+
+  Scenario: The service is healthy
+    When I send a "GET" request to "/health"
+    Then the response status should be 200
+
+  Scenario: Create and fetch a user
+    Given I generate fake data: "email={email}, name={firstname} {lastname}"
+    When I send a "POST" request to "/users" with payload:
+      """
+      {
+        "email": "${email}",
+        "name": "${name}"
+      }
+      """
+    Then the response status should be 201
+    And I store the response property "id" as "user_id"
+    When I send a "GET" request to "/users/${user_id}"
+    Then the response status should be 200
+    And the response property "email" should be "${email}"
+`
+
+const configTemplate = `# rbdd configuration. Every option can also be passed as a flag to "rbdd run".
+strict: true
+env: local
+
+environments:
+  local:
+    base_url: %s
+    variables: {}
+`
+
+const gitignoreEntries = `# rbdd reports
+reports/
+`
+
+// scaffoldFile is a file created by rbdd init.
+type scaffoldFile struct {
+	path    string
+	content string
+}
+
+var ciTemplates = map[string]scaffoldFile{
+	"github": {
+		path: ".github/workflows/rbdd.yml",
+		content: `name: rbdd
+
+on: [push, pull_request]
+
+jobs:
+  rbdd:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+      - run: go install github.com/davesavic/rbdd@latest
+      - run: rbdd lint
+      # Start the service here, then point --env at it.
+      - run: rbdd run --env local
+`,
+	},
+	"gitlab": {
+		path: ".gitlab-ci.yml",
+		content: `rbdd:
+  image: golang:latest
+  script:
+    - go install github.com/davesavic/rbdd@latest
+    - rbdd lint
+    # Start the service here, then point --env at it.
+    - rbdd run --env local
+`,
+	},
+}
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init [directory]",
+	Short: "Create a new rbdd project",
+	Long: `Create a features directory with a sample feature, an rbdd.yaml with a local
+environment, a .gitignore entry for reports and optionally a CI workflow.
+Prompts for the options not given as flags when run in a terminal, and never
+overwrites existing files.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+
+		baseURL, _ := cmd.Flags().GetString("base-url")
+		ci, _ := cmd.Flags().GetString("ci")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		if !yes && isTerminal(os.Stdin) {
+			reader := bufio.NewReader(cmd.InOrStdin())
+			if !cmd.Flags().Changed("base-url") {
+				baseURL = prompt(cmd.OutOrStdout(), reader, "Base URL of the local environment", baseURL)
+			}
+			if !cmd.Flags().Changed("ci") {
+				ci = prompt(cmd.OutOrStdout(), reader, "CI workflow (github, gitlab, none)", ci)
+			}
+		}
+
+		files := []scaffoldFile{
+			{filepath.Join("features", "example.feature"), sampleFeature},
+			{projectConfigFile, fmt.Sprintf(configTemplate, baseURL)},
+		}
+		if ci != "none" {
+			template, ok := ciTemplates[ci]
+			if !ok {
+				return fmt.Errorf("unknown CI %q, expected github, gitlab or none", ci)
+			}
+			files = append(files, template)
+		}
+
+		var existing []string
+		for _, file := range files {
+			if _, err := os.Stat(filepath.Join(dir, file.path)); err == nil {
+				existing = append(existing, file.path)
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("refusing to overwrite existing files: %s", strings.Join(existing, ", "))
+		}
+
+		for _, file := range files {
+			target := filepath.Join(dir, file.path)
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(target, []byte(file.content), 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Created %s\n", target)
+		}
+
+		updated, err := appendGitignore(filepath.Join(dir, ".gitignore"))
+		if err != nil {
+			return err
+		}
+		if updated {
+			fmt.Fprintf(cmd.OutOrStdout(), "Updated %s\n", filepath.Join(dir, ".gitignore"))
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "\nRun the sample feature with: rbdd run")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().String("base-url", "http://localhost:8080", "Base URL of the local environment")
+	initCmd.Flags().String("ci", "none", "CI workflow to create: github, gitlab or none")
+	initCmd.Flags().BoolP("yes", "y", false, "Use the flags and defaults without prompting")
+}
+
+// appendGitignore adds the rbdd entries to a .gitignore, creating it if
+// needed. Existing entries are kept.
+func appendGitignore(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if strings.Contains(string(content), gitignoreEntries) {
+		return false, nil
+	}
+
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		content = append(content, '\n')
+	}
+	if len(content) > 0 {
+		content = append(content, '\n')
+	}
+
+	return true, os.WriteFile(path, append(content, gitignoreEntries...), 0o644)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// prompt asks a question on w, returning the default for an empty answer.
+func prompt(w io.Writer, r *bufio.Reader, question, def string) string {
+	fmt.Fprintf(w, "%s [%s]: ", question, def)
+	answer, _ := r.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return def
+}
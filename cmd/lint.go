@@ -0,0 +1,54 @@
+/*
+Copyright © 2025 Dave Savic
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/davesavic/rbdd/app"
+	"github.com/spf13/cobra"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check feature files without running them",
+	Long: `Check feature files for undefined steps, references to variables that are
+not set earlier in the scenario or background, and malformed JSON docstrings.
+Exits with a non-zero status when problems are found.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		directories, err := cmd.Flags().GetStringSlice("directories")
+		if err != nil || len(directories) == 0 {
+			directories = []string{"features"}
+		}
+
+		variables, err := loadVariables()
+		if err != nil {
+			return err
+		}
+
+		issues, err := app.Lint(directories, variables)
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range issues {
+			fmt.Fprintln(cmd.OutOrStdout(), issue)
+		}
+		if len(issues) > 0 {
+			return fmt.Errorf("found %d problem(s)", len(issues))
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "No problems found")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringSliceP("directories", "d", []string{"features"}, "Directories containing the feature files to check")
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2025 Dave Savic
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/davesavic/rbdd/app"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// mockCmd represents the mock command
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Serve canned responses from stub files",
+	Long: `Run an HTTP server answering requests with the stubs defined in the YAML and
+JSON files of a directory. Each stub matches on method, path pattern, query,
+headers and body, and templates its response with ${...} placeholders and fake
+data tags. Requests are logged, and stub files are reloaded when they change.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("stubs")
+		port, _ := cmd.Flags().GetInt("port")
+
+		variables, err := loadVariables()
+		if err != nil {
+			return err
+		}
+		generators, err := app.ParseGenerators(viper.GetStringMap("generators"))
+		if err != nil {
+			return err
+		}
+
+		cfg := app.Config{
+			Variables:  variables,
+			Locale:     viper.GetString("locale"),
+			Generators: generators,
+		}
+		stubs, err := app.NewStubServer(dir, cfg, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: stubs}
+		errs := make(chan error, 2)
+		go func() { errs <- server.ListenAndServe() }()
+		go func() { errs <- stubs.Watch(ctx.Done()) }()
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Serving %d stubs from %s on http://localhost:%d\n", stubs.Len(), dir, port)
+		for _, route := range stubs.Routes() {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", route)
+		}
+
+		select {
+		case err := <-errs:
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+		}
+
+		if err := server.Shutdown(context.Background()); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mockCmd)
+
+	mockCmd.Flags().String("stubs", "stubs", "Directory of YAML and JSON stub files")
+	mockCmd.Flags().Int("port", 8081, "Port to serve the stubs on")
+}
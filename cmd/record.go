@@ -0,0 +1,91 @@
+/*
+Copyright © 2025 Dave Savic
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/davesavic/rbdd/app"
+	"github.com/spf13/cobra"
+)
+
+// recordCmd represents the record command
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record requests through a proxy into a feature file",
+	Long: `Run a reverse proxy in front of a service and, when stopped with Ctrl+C, write
+the requests sent through it as a feature. Each request asserts its recorded
+status and the stable parts of its JSON response, and identifiers returned by
+one request that appear in later URLs are stored and referenced as variables.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, _ := cmd.Flags().GetString("listen")
+		upstream, _ := cmd.Flags().GetString("upstream")
+		out, _ := cmd.Flags().GetString("out")
+		force, _ := cmd.Flags().GetBool("force")
+
+		if upstream == "" {
+			return fmt.Errorf("--upstream is required")
+		}
+		if _, err := os.Stat(out); err == nil && !force {
+			return fmt.Errorf("refusing to overwrite %s (use --force)", out)
+		}
+
+		recorder, err := app.NewRecorder(upstream, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		server := &http.Server{Addr: listen, Handler: recorder}
+		errs := make(chan error, 1)
+		go func() { errs <- server.ListenAndServe() }()
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Recording requests to %s on %s, press Ctrl+C to stop\n", upstream, listen)
+
+		select {
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+		}
+		if err := server.Shutdown(context.Background()); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+
+		if recorder.Len() == 0 {
+			return fmt.Errorf("no requests were recorded")
+		}
+
+		name := strings.TrimSuffix(filepath.Base(out), filepath.Ext(out))
+		if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(out, []byte(recorder.Feature(name)), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\nCreated %s with %d requests\n", out, recorder.Len())
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+
+	recordCmd.Flags().String("listen", ":9000", "Address for the recording proxy to listen on")
+	recordCmd.Flags().String("upstream", "", "URL of the service to forward requests to")
+	recordCmd.Flags().StringP("out", "o", "features/recorded.feature", "Feature file to write the recording to")
+	recordCmd.Flags().Bool("force", false, "Overwrite an existing feature file")
+}
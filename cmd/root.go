@@ -5,6 +5,7 @@ Copyright © 2025 Dave Savic
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -17,13 +18,17 @@ var (
 	Version = "v0.0.1"
 )
 
+// projectConfigFile is the per-project config looked up in the working directory.
+const projectConfigFile = "rbdd.yaml"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "rbdd",
-	Short: "Test your backend api with a simple command line tool and a few cucumber tests",
-	Long:  `A simple command line tool to test your backend api using cucumber tests written in gherkin syntax. Easily generate fake data using faker and test your api with a few simple commands. Run your tests in parallel and get the results in a simple format.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		runCmd.Run(cmd, args)
+	Use:          "rbdd",
+	Short:        "Test your backend api with a simple command line tool and a few cucumber tests",
+	Long:         `A simple command line tool to test your backend api using cucumber tests written in gherkin syntax. Easily generate fake data using faker and test your api with a few simple commands. Run your tests in parallel and get the results in a simple format.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCmd.RunE(cmd, args)
 	},
 }
 
@@ -43,7 +48,7 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.rbdd.env)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./rbdd.yaml, then $HOME/.rbdd.env)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -55,6 +60,9 @@ func initConfig() {
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
+	} else if _, err := os.Stat(projectConfigFile); err == nil {
+		// Prefer the project config in the working directory.
+		viper.SetConfigFile(projectConfigFile)
 	} else {
 		// Find home directory.
 		home, err := os.UserHomeDir()
@@ -66,10 +74,20 @@ func initConfig() {
 		viper.SetConfigName(".rbdd")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// Read in environment variables that match, prefixed so that common
+	// variables such as ENV do not select options by accident.
+	viper.SetEnvPrefix("rbdd")
+	viper.AutomaticEnv()
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
+	// If a config file is found, read it in. Only a missing home config is
+	// tolerated; a config that exists but cannot be read is an error.
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			fmt.Fprintf(os.Stderr, "Error: failed to read config file %s: %v\n", viper.ConfigFileUsed(), err)
+			os.Exit(1)
+		}
+	} else {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
@@ -5,37 +5,94 @@ Copyright © 2025 Dave Savic
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
 
 	"github.com/cucumber/godog"
 	"github.com/davesavic/rbdd/app"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // runCmd represents the run command
 var runCmd = &cobra.Command{
-	Use:   "run",
-	Short: "Run the cucumber tests",
-	Long:  `Run the cucumber tests using the gherkin syntax.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:          "run",
+	Short:        "Run the cucumber tests",
+	Long:         `Run the cucumber tests using the gherkin syntax.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		directories, err := cmd.Flags().GetStringSlice("directories")
 		if err != nil || len(directories) == 0 {
 			directories = []string{"features"}
 		}
 
+		variables, err := loadVariables()
+		if err != nil {
+			return err
+		}
+
+		generators, err := app.ParseGenerators(viper.GetStringMap("generators"))
+		if err != nil {
+			return err
+		}
+
+		seed := viper.GetUint64("seed")
+		if seed == 0 {
+			seed = rand.Uint64N(math.MaxUint32) + 1
+		}
+		fmt.Printf("Using fake data seed %d (rerun with --seed %d to reproduce)\n", seed, seed)
+
+		cfg := app.Config{
+			BaseURL:     environmentOption("base_url"),
+			Strict:      viper.GetBool("strict"),
+			Variables:   variables,
+			Seed:        seed,
+			Locale:      viper.GetString("locale"),
+			Generators:  generators,
+			MaskSecrets: viper.GetBool("mask_secrets"),
+
+			WebhookSecret:          viper.GetString("webhook_secret"),
+			WebhookSignatureHeader: viper.GetString("webhook_signature_header"),
+			XMLNamespaces:          viper.GetStringMapString("xml_namespaces"),
+			GRPCAddress:            viper.GetString("grpc_address"),
+			ProtoFiles:             viper.GetStringSlice("proto_files"),
+			ProtoImportPaths:       viper.GetStringSlice("proto_import_paths"),
+			Timings:                &app.Timings{},
+		}
+
+		// Outlines using @examples(file=...) are expanded before godog
+		// parses them, so their files are read with the rows added.
+		paths, fsys, err := app.FeatureSource(directories)
+		if err != nil {
+			return err
+		}
+
+		// Expanded features are reported at the lines of their files.
+		godog.Format("rbdd-lines", "Reports expanded features at their source lines", fsys.Formatter())
+
+		options := &godog.Options{
+			Format: "rbdd-lines,pretty",
+			Paths:  paths,
+			FS:     fsys,
+		}
+
 		suite := godog.TestSuite{
 			Name:                 "rbdd",
-			TestSuiteInitializer: app.InitializeTestSuite,
-			Options: &godog.Options{
-				Format: "pretty",
-				Paths:  directories,
-			},
+			TestSuiteInitializer: app.TestSuiteInitializer(cfg),
+			Options:              options,
 		}
 
-		if suite.Run() != 0 {
-			fmt.Println("Test suite failed")
-			return
+		status := suite.Run()
+		cfg.Timings.WriteSummary(os.Stdout, viper.GetInt("slowest"))
+
+		if status != 0 {
+			return errors.New("test suite failed")
 		}
+
+		return nil
 	},
 }
 
@@ -43,4 +100,70 @@ func init() {
 	rootCmd.AddCommand(runCmd)
 
 	runCmd.Flags().StringSliceP("directories", "d", []string{"features"}, "Directories to run the tests in")
+	runCmd.Flags().String("env", "", "Environment from the config file's environments to run against")
+	runCmd.Flags().Bool("strict", false, "Fail steps that reference undefined ${variables}")
+	runCmd.Flags().Uint64("seed", 0, "Seed for reproducible fake data (random when 0)")
+	runCmd.Flags().String("locale", "", "Locale for fake names, addresses and phone numbers (de, fr)")
+	runCmd.Flags().StringSlice("vars", nil, "Files (.env, .json, .yaml) to load variables from")
+	runCmd.Flags().StringSlice("import-env", nil, "Environment variables to import into the store")
+	runCmd.Flags().Bool("mask-secrets", false, "Mask credentials in the curl commands printed for requests")
+	runCmd.Flags().Int("slowest", 5, "Number of slowest requests to list after the run (0 to hide)")
+
+	cobra.CheckErr(viper.BindPFlag("env", runCmd.Flags().Lookup("env")))
+	cobra.CheckErr(viper.BindPFlag("strict", runCmd.Flags().Lookup("strict")))
+	cobra.CheckErr(viper.BindPFlag("seed", runCmd.Flags().Lookup("seed")))
+	cobra.CheckErr(viper.BindPFlag("locale", runCmd.Flags().Lookup("locale")))
+	cobra.CheckErr(viper.BindPFlag("vars", runCmd.Flags().Lookup("vars")))
+	cobra.CheckErr(viper.BindPFlag("import_env", runCmd.Flags().Lookup("import-env")))
+	cobra.CheckErr(viper.BindPFlag("mask_secrets", runCmd.Flags().Lookup("mask-secrets")))
+	cobra.CheckErr(viper.BindPFlag("slowest", runCmd.Flags().Lookup("slowest")))
+}
+
+// environmentOption returns an option of the environment selected with
+// --env, such as "environments.local.base_url", or "" when none is selected.
+func environmentOption(option string) string {
+	env := viper.GetString("env")
+	if env == "" {
+		return ""
+	}
+	return viper.GetString("environments." + env + "." + option)
+}
+
+// loadVariables collects the initial store from the "variables" config map,
+// the selected environment's variables, the "vars" files and the
+// "import_env" environment variables, in that order.
+func loadVariables() (map[string]any, error) {
+	if env := viper.GetString("env"); env != "" && !viper.IsSet("environments."+env) {
+		return nil, fmt.Errorf("environment %q is not defined in the config file", env)
+	}
+
+	variables := map[string]any{}
+	for k, v := range viper.GetStringMap("variables") {
+		variables[k] = v
+	}
+	if env := viper.GetString("env"); env != "" {
+		for k, v := range viper.GetStringMap("environments." + env + ".variables") {
+			variables[k] = v
+		}
+	}
+
+	for _, file := range viper.GetStringSlice("vars") {
+		vars, err := app.LoadVariables(file)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vars {
+			variables[k] = v
+		}
+	}
+
+	vars, err := app.LoadEnvironmentVariables(viper.GetStringSlice("import_env"))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range vars {
+		variables[k] = v
+	}
+
+	return variables, nil
 }
@@ -5,6 +5,12 @@ Copyright © 2025 Dave Savic
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/davesavic/rbdd/app"
 	"github.com/spf13/cobra"
 )
 
@@ -13,135 +19,84 @@ var syntaxCmd = &cobra.Command{
 	Use:   "syntax",
 	Short: "Show the gherkin syntax available",
 	Long:  `Show the gherkin syntax available for use in the tests`,
-	Run: func(cmd *cobra.Command, args []string) {
-		output := `
---- Making requests ---
-Gherkin Syntax: I send a "METHOD" request to "ENDPOINT"
-Description: This step sends a request to the specified endpoint using the specified HTTP method (GET, POST, PUT, DELETE, etc.).
-Example: Given/When I send a "GET" request to "/api/users"
-
-Gherkin Syntax: I send a "METHOD" request to "ENDPOINT" with payload:
-Description: This step sends a request to the specified endpoint using the specified HTTP method with the provided payload.
-Example: 
-Given/When I send a "POST" request to "/api/users" with payload:
-  """
-  {
-	"name": "John Doe",
-	"email": "john@example.com"
-  }
-  """
-
---- Validating responses ---
-Gherkin Syntax: the response status should be STATUS_CODE
-Description: This step checks if the response status code matches the expected status code.
-Example: Then the response status should be 200
+	RunE: func(cmd *cobra.Command, args []string) error {
+		grep, _ := cmd.Flags().GetString("grep")
+		format, _ := cmd.Flags().GetString("format")
+
+		var steps []app.StepDefinition
+		for _, step := range app.Steps() {
+			if matchesGrep(step, grep) {
+				steps = append(steps, step)
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		switch format {
+		case "text":
+			writeSyntaxText(out, steps)
+		case "markdown":
+			writeSyntaxMarkdown(out, steps)
+		case "json":
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false)
+			return encoder.Encode(steps)
+		default:
+			return fmt.Errorf("unknown format %q, expected text, markdown or json", format)
+		}
+
+		return nil
+	},
+}
 
-Gherkin Syntax: the response property "JSON_PATH" should be VALUE
-Description: This step checks if the response property at the specified JSON path matches the expected value.
-Example: Then the response property "data.user.email" should be "john@example.com"
+func init() {
+	rootCmd.AddCommand(syntaxCmd)
 
-Gherkin Syntax: the response property "JSON_PATH" should not be empty
-Description: This step checks if the response property at the specified JSON path is not empty.
-Example: Then the response property "data.user.id" should not be empty
+	syntaxCmd.Flags().StringP("grep", "g", "", "Only show steps whose syntax, description or category contains this text")
+	syntaxCmd.Flags().StringP("format", "f", "text", "Output format: text, markdown or json")
+}
 
-Gherkin Syntax: the response should match JSON:
-Description: This step checks if the entire response matches the expected JSON structure.
-Example:
-Then the response should match JSON:
-  """
-  {
-	"success": true,
-	"data": {
-	  "id": 123,
-	  "name": "John Doe"
+func matchesGrep(step app.StepDefinition, grep string) bool {
+	if grep == "" {
+		return true
 	}
-  }
-  """
-
-Gherkin Syntax: the response should contain JSON:
-Description: This step checks if the response contains the specified JSON structure.
-Example:
-Then the response should contain JSON:
-  """
-  {
-	"success": true
-  }
-  """
-
---- Managing state ---
-Gherkin Syntax: I store the response property "JSON_PATH" as "VARIABLE_NAME"
-Description: This step stores the value of the response property at the specified JSON path into a variable.
-Example: And I store the response property "data.token" as "auth_token"
-
-Gherkin Syntax: I store the command output as "VARIABLE_NAME"
-Description: This step stores the output of a command into a variable.
-Example: And I store the command output as "db_result"
-
-Gherkin Syntax: I store "VALUE" as "VARIABLE_NAME"
-Description: This step stores a specified value into a variable.
-Example: And I store "Bearer ${auth_token}" as "authorization"
-
-Gherkin Syntax: I set header "HEADER_NAME" to "HEADER_VALUE"
-Description: This step sets a specified header to a specified value.
-Example: And I set header "Authorization" to "${authorization}"
-
-Gherkin Syntax: I reset all variables
-Description: This step resets all stored variables to their initial state.
-Example: And I reset all variables
-
-Gherkin Syntax: I reset variables "VARIABLE_LIST"
-Description: This step resets specified variables to their initial state.
-Example: And I reset variables "user_id, auth_token"
-
---- Data generation ---
-Gherkin Syntax: I generate fake data: "PATTERN"
-Description: This step generates fake data based on the specified pattern using the gofakeit library.
-Example: Given I generate fake data: "email={email}, name={firstname} {lastname}, phone={phone}"
-
---- Command execution ---
-Gherkin Syntax: I execute command "COMMAND"
-Description: This step executes a specified command in the shell.
-Example: When I execute command "echo 'Hello World'"
-
-Gherkin Syntax: I execute command "COMMAND" in directory "DIRECTORY"
-Description: This step executes a specified command in the shell within a specified directory.
-Example: When I execute command "npm install" in directory "./frontend"
-
-Gherkin Syntax: I execute command "COMMAND" with timeout SECONDS
-Description: This step executes a specified command in the shell with a specified timeout in seconds.
-Example: When I execute command "gradle build" with timeout 30
-
-Gherkin Syntax: the command output should match "PATTERN"
-Description: This step checks if the command output matches the specified pattern.
-Example: Then the command output should match "Success"
-
-Gherkin Syntax: the command output should contain "TEXT"
-Description: This step checks if the command output contains the specified text.
-Example: Then the command output should contain "Build completed"
-
---- Debugging ---
-Gherkin Syntax: I start debugging
-Description: This step starts the debugging mode, allowing for detailed output during test execution.
-Example: Given I start debugging
-
-Gherkin Syntax: I stop debugging
-Description: This step stops the debugging mode.
-Example: Given I stop debugging
-		`
-		cmd.Println(output)
-	},
+	grep = strings.ToLower(grep)
+	for _, field := range []string{step.Syntax, step.Description, step.Category} {
+		if strings.Contains(strings.ToLower(field), grep) {
+			return true
+		}
+	}
+	return false
 }
 
-func init() {
-	rootCmd.AddCommand(syntaxCmd)
+func writeSyntaxText(w io.Writer, steps []app.StepDefinition) {
+	category := ""
+	for _, step := range steps {
+		if step.Category != category {
+			category = step.Category
+			fmt.Fprintf(w, "\n--- %s ---\n", category)
+		}
+
+		fmt.Fprintf(w, "Gherkin Syntax: %s\n", step.Syntax)
+		fmt.Fprintf(w, "Description: %s\n", step.Description)
+		if strings.Contains(step.Example, "\n") {
+			fmt.Fprintf(w, "Example:\n%s\n\n", step.Example)
+		} else {
+			fmt.Fprintf(w, "Example: %s\n\n", step.Example)
+		}
+	}
+}
 
-	// Here you will define your flags and configuration settings.
+func writeSyntaxMarkdown(w io.Writer, steps []app.StepDefinition) {
+	fmt.Fprintln(w, "# rbdd step reference")
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// syntaxCmd.PersistentFlags().String("foo", "", "A help for foo")
+	category := ""
+	for _, step := range steps {
+		if step.Category != category {
+			category = step.Category
+			fmt.Fprintf(w, "\n## %s\n", category)
+		}
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// syntaxCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+		fmt.Fprintf(w, "\n### `%s`\n\n%s\n\n```gherkin\n%s\n```\n", step.Syntax, step.Description, step.Example)
+	}
 }
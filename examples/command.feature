@@ -1,7 +1,7 @@
 Feature: Command Testing
   Scenario: Execute command store result
     Given I execute command "ls -l"
-    And I store command output as "command_result"
+    And I store the command output as "command_result"
 
 
 